@@ -0,0 +1,186 @@
+package structer
+
+import (
+	"go/types"
+	"strconv"
+	"strings"
+)
+
+// StructNamer chooses a name for an anonymous struct found at path when
+// walking root. It does not need to avoid collisions; AnonStructs does that.
+type StructNamer func(root TypeName, path WalkPath) string
+
+// DefaultStructNamer joins the root's name and the names of the fields used
+// to reach the struct with underscores. Map keys and elems contribute "Key"
+// and "Value", type assertions contribute the asserted type's name, and
+// slices, arrays and pointers contribute nothing, i.e. the struct at
+// Pants.Foo[0].Bar (a map key) is called "Pants_Foo_Bar_Key".
+func DefaultStructNamer(root TypeName, path WalkPath) string {
+	parts := []string{root.Name}
+	for _, elem := range path {
+		switch elem.Kind {
+		case PathField:
+			parts = append(parts, elem.Field.Name())
+		case PathMapKey:
+			parts = append(parts, "Key")
+		case PathMapElem:
+			parts = append(parts, "Value")
+		case PathTypeAssert:
+			if named := namedOf(elem.Type); named != nil {
+				parts = append(parts, named.Obj().Name())
+			}
+		}
+	}
+	return strings.Join(parts, "_")
+}
+
+// AnonStruct is a distinct anonymous struct found by AnonStructs.
+type AnonStruct struct {
+	Name   string
+	Struct *types.Struct
+
+	// Root and Path locate the first place the struct was found. Other
+	// places an identical struct was found are listed in Seen.
+	Root TypeName
+	Path WalkPath
+	Seen []AnonStructUse
+}
+
+// AnonStructUse is a place an anonymous struct was found.
+type AnonStructUse struct {
+	Root TypeName
+	Path WalkPath
+}
+
+// AnonStructs assigns stable, unique names to the anonymous structs nested
+// inside one or more root types, so a generator can declare a named type for
+// each of them.
+//
+// Structs that are identical according to types.Identical (including field
+// names, types and tags) share a single name, so each needs to be declared
+// only once. Names are assigned in the order the structs are found, so they
+// are stable as long as the roots are added in the same order. If the
+// StructNamer produces a name that is already taken, a numeric suffix is
+// appended.
+//
+type AnonStructs struct {
+	Namer StructNamer
+
+	// Structs contains each distinct anonymous struct in the order it was
+	// found.
+	Structs []*AnonStruct
+
+	taken map[string]bool
+}
+
+func NewAnonStructs(namer StructNamer) *AnonStructs {
+	if namer == nil {
+		namer = DefaultStructNamer
+	}
+	return &AnonStructs{Namer: namer, taken: make(map[string]bool)}
+}
+
+// Reserve prevents names from being assigned to anonymous structs, i.e. to
+// avoid collisions with the existing declarations in the target package.
+func (a *AnonStructs) Reserve(names ...string) {
+	for _, name := range names {
+		a.taken[name] = true
+	}
+}
+
+// Add walks t, which is usually the underlying type of root, and names each
+// anonymous struct it contains. The root itself is not named. The options
+// are passed to Walk.
+//
+// If the struct containing a nested anonymous struct was given a different
+// name to the one chosen by the Namer, i.e. because of a collision, the
+// nested struct's name is based on the assigned name, as long as the Namer
+// builds names for nested structs by extending the name of their parent.
+//
+func (a *AnonStructs) Add(root TypeName, t types.Type, opts ...WalkOption) error {
+	// parents contains the anonymous structs enclosing the current one.
+	var parents []anonParent
+	vis := &PartialTypeVisitor{
+		EnterStructFunc: func(ctx WalkContext, s StructInfo) error {
+			path := ctx.Path()
+			if isAnonStructPath(path) {
+				var parent *anonParent
+				if len(parents) > 0 {
+					parent = &parents[len(parents)-1]
+				}
+				name := a.add(root, path, s.Struct, parent)
+				parents = append(parents, anonParent{path: path, name: name})
+			}
+			return nil
+		},
+		LeaveStructFunc: func(ctx WalkContext, s StructInfo) error {
+			if isAnonStructPath(ctx.Path()) {
+				parents = parents[:len(parents)-1]
+			}
+			return nil
+		},
+	}
+	return Walk(root, t, vis, opts...)
+}
+
+type anonParent struct {
+	path WalkPath
+	name string
+}
+
+// Find returns the AnonStruct that is identical to st, or nil if there isn't
+// one.
+func (a *AnonStructs) Find(st *types.Struct) *AnonStruct {
+	for _, anon := range a.Structs {
+		if anon.Struct == st || types.Identical(anon.Struct, st) {
+			return anon
+		}
+	}
+	return nil
+}
+
+// Name returns the name assigned to the anonymous struct st.
+func (a *AnonStructs) Name(st *types.Struct) (name string, ok bool) {
+	if anon := a.Find(st); anon != nil {
+		return anon.Name, true
+	}
+	return "", false
+}
+
+func (a *AnonStructs) add(root TypeName, path WalkPath, st *types.Struct, parent *anonParent) string {
+	if anon := a.Find(st); anon != nil {
+		anon.Seen = append(anon.Seen, AnonStructUse{Root: root, Path: path})
+		return anon.Name
+	}
+
+	base := a.Namer(root, path)
+	if parent != nil {
+		if prefix := a.Namer(root, parent.path); prefix != parent.name && len(base) > len(prefix) && strings.HasPrefix(base, prefix) {
+			base = parent.name + base[len(prefix):]
+		}
+	}
+	name := base
+	for i := 2; a.taken[name]; i++ {
+		name = base + strconv.Itoa(i)
+	}
+	a.taken[name] = true
+	a.Structs = append(a.Structs, &AnonStruct{Name: name, Struct: st, Root: root, Path: path})
+	return name
+}
+
+// isAnonStructPath reports whether a struct found at path is anonymous. The
+// struct at the root of the Walk, and the underlying struct of an
+// implementer visited using WalkImplementers, belong to named types, but a
+// struct pointed to by the root does not.
+func isAnonStructPath(path WalkPath) bool {
+	for i := len(path) - 1; i >= 0; i-- {
+		switch path[i].Kind {
+		case PathDeref:
+			continue
+		case PathTypeAssert:
+			return false
+		}
+		return true
+	}
+	return len(path) > 0
+}
@@ -0,0 +1,77 @@
+package structer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAnonStructs(t *testing.T) {
+	pkg := "github.com/shabbyrobe/structer/testpkg/anon"
+	tpset := NewTypePackageSet()
+	if _, err := tpset.Import(pkg); err != nil {
+		t.Fatal(err)
+	}
+
+	anons := NewAnonStructs(nil)
+	anons.Reserve("Pants_Baz")
+	for _, name := range []string{"Pants", "Shirt"} {
+		tn := NewTypeName(pkg, name)
+		if err := anons.Add(tn, tpset.MustFindObject(tn).Type().Underlying()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var names, paths []string
+	for _, anon := range anons.Structs {
+		names = append(names, anon.Name)
+		paths = append(paths, anon.Path.Expr("v"))
+	}
+	expected := []string{
+		"Pants_Foo",
+		"Pants_Foo_Bar_Key",
+		"Pants_Point",
+		"Pants_Baz2",
+		"Pants_Baz2_Qux",
+		"Shirt_Other",
+	}
+	if !reflect.DeepEqual(expected, names) {
+		t.Fatal(names)
+	}
+
+	point := anons.Structs[2]
+	if len(point.Seen) != 1 || point.Seen[0].Root.Name != "Shirt" || point.Seen[0].Path.Expr("v") != "v.Point" {
+		t.Fatal(point.Seen)
+	}
+
+	shirt := structOf(tpset.MustFindObject(NewTypeName(pkg, "Shirt")).Type())
+	if name, ok := anons.Name(structOf(shirt.Field(0).Type())); !ok || name != "Pants_Point" {
+		t.Fatal(name)
+	}
+	if name, ok := anons.Name(shirt); ok {
+		t.Fatal(name)
+	}
+}
+
+func TestAnonStructsNamer(t *testing.T) {
+	pkg := "github.com/shabbyrobe/structer/testpkg/anon"
+	tpset := NewTypePackageSet()
+	if _, err := tpset.Import(pkg); err != nil {
+		t.Fatal(err)
+	}
+
+	anons := NewAnonStructs(func(root TypeName, path WalkPath) string {
+		return "Anon"
+	})
+	tn := NewTypeName(pkg, "Pants")
+	if err := anons.Add(tn, tpset.MustFindObject(tn).Type().Underlying()); err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, anon := range anons.Structs {
+		names = append(names, anon.Name)
+	}
+	if !reflect.DeepEqual([]string{"Anon", "Anon2", "Anon3", "Anon4", "Anon5"}, names) {
+		t.Fatal(names)
+	}
+}
@@ -96,6 +96,13 @@ type ASTPackageSet struct {
 
 	Decls map[token.Pos]ast.Decl
 
+	// Fields indexes every struct field by the position of each of its
+	// names, or by the position of the type name for embedded fields. These
+	// are the same positions reported by types.Var.Pos. StructTypes maps
+	// each field back to the struct that contains it.
+	Fields      map[token.Pos]*ast.Field
+	StructTypes map[*ast.Field]*ast.StructType
+
 	Imported map[string]bool
 }
 
@@ -108,10 +115,76 @@ func NewASTPackageSet() *ASTPackageSet {
 		GenDecls: make(map[*ast.TypeSpec]*ast.GenDecl),
 		Decls:    make(map[token.Pos]ast.Decl),
 		Imported: make(map[string]bool),
+
+		Fields:      make(map[token.Pos]*ast.Field),
+		StructTypes: make(map[*ast.Field]*ast.StructType),
 	}
 	return pkgs
 }
 
+// FindField returns the AST for the struct field declared at pos, i.e. the
+// position of a *types.Var returned by types.Struct.Field, or nil if the
+// field's package has not been added.
+func (p *ASTPackageSet) FindField(pos token.Pos) *ast.Field {
+	return p.Fields[pos]
+}
+
+// FindStructType returns the AST for the struct that contains field.
+func (p *ASTPackageSet) FindStructType(field *ast.Field) *ast.StructType {
+	return p.StructTypes[field]
+}
+
+// NodeSource returns the source code for node exactly as it was written,
+// including any comments and whitespace within it.
+func (p *ASTPackageSet) NodeSource(pkgPath string, node ast.Node) ([]byte, error) {
+	astPkg := p.Packages[pkgPath]
+	if astPkg == nil {
+		return nil, fmt.Errorf("could not find package %s", pkgPath)
+	}
+	start := p.FileSet.PositionFor(node.Pos(), false)
+	end := p.FileSet.PositionFor(node.End(), false)
+	contents, ok := astPkg.Contents[filepath.Base(start.Filename)]
+	if !ok || start.Filename != end.Filename || end.Offset > len(contents) {
+		return nil, fmt.Errorf("could not find source for node at %s", start)
+	}
+	return contents[start.Offset:end.Offset], nil
+}
+
+func (p *ASTPackageSet) indexFields(file *ast.File) {
+	ast.Inspect(file, func(node ast.Node) bool {
+		st, ok := node.(*ast.StructType)
+		if !ok || st.Fields == nil {
+			return true
+		}
+		for _, field := range st.Fields.List {
+			p.StructTypes[field] = st
+			if len(field.Names) == 0 {
+				p.Fields[embeddedFieldPos(field.Type)] = field
+			}
+			for _, name := range field.Names {
+				p.Fields[name.Pos()] = field
+			}
+		}
+		return true
+	})
+}
+
+// embeddedFieldPos returns the position go/types uses for an embedded field
+// with the type expression expr, which is the position of the type's name.
+func embeddedFieldPos(expr ast.Expr) token.Pos {
+	switch e := expr.(type) {
+	case *ast.StarExpr:
+		return embeddedFieldPos(e.X)
+	case *ast.SelectorExpr:
+		return e.Sel.Pos()
+	case *ast.IndexExpr:
+		return embeddedFieldPos(e.X)
+	case *ast.IndexListExpr:
+		return embeddedFieldPos(e.X)
+	}
+	return expr.Pos()
+}
+
 func (p *ASTPackageSet) FindNodeByPackagePathPos(pkgPath string, pos token.Pos) ast.Node {
 	// FIXME: investigate what a "position altering comment" does to this.
 	posn := p.FileSet.PositionFor(pos, false)
@@ -286,6 +359,8 @@ func (p *ASTPackageSet) Add(dir string, pkg string) error {
 			}
 		}
 
+		p.indexFields(astFile)
+
 		for _, decl := range astFile.Decls {
 			if _, ok := p.Decls[decl.Pos()]; ok {
 				panic("bug: decl already exists")
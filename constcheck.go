@@ -0,0 +1,132 @@
+package structer
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+)
+
+// ConstIssueKind identifies a problem found by CheckConsts.
+type ConstIssueKind int
+
+const (
+	// ConstDuplicate is a constant with the same value as an earlier one.
+	ConstDuplicate ConstIssueKind = iota + 1
+
+	// ConstGap is a constant that follows unused iota values in its const
+	// block, i.e. because a value was replaced with "_".
+	ConstGap
+
+	// ConstUnexported is an unexported value of an exported type.
+	ConstUnexported
+
+	// ConstForeign is a value declared outside the type's package.
+	ConstForeign
+)
+
+func (k ConstIssueKind) String() string {
+	switch k {
+	case ConstDuplicate:
+		return "duplicate"
+	case ConstGap:
+		return "gap"
+	case ConstUnexported:
+		return "unexported"
+	case ConstForeign:
+		return "foreign"
+	default:
+		return fmt.Sprintf("ConstIssueKind(%d)", int(k))
+	}
+}
+
+// ConstIssue is a problem with one of the values of a type, found by
+// CheckConsts.
+type ConstIssue struct {
+	Kind  ConstIssueKind
+	Type  TypeName
+	Value *ConstValue
+
+	// Other is the earlier constant with the same value for ConstDuplicate.
+	Other *ConstValue
+
+	Message string
+}
+
+func (i ConstIssue) String() string {
+	return fmt.Sprintf("%s: %s", i.Value.Position, i.Message)
+}
+
+// CheckConsts looks for problems with the values of the type name that could
+// cause values to change unexpectedly, which matters if they are stored or
+// sent over the wire. The constants are found using ExtractConstsWith,
+// searching every imported package and including unexported constants. The
+// issues are returned in the order the constants are declared.
+//
+// Duplicates are reported even if they are deliberate aliases.
+//
+func (t *TypePackageSet) CheckConsts(name TypeName) ([]ConstIssue, error) {
+	consts, err := t.ExtractConstsWith(name, ConstsOptions{AllPackages: true, IncludeUnexported: true})
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []ConstIssue
+	add := func(kind ConstIssueKind, cv, other *ConstValue, msg string, args ...interface{}) {
+		issues = append(issues, ConstIssue{
+			Kind: kind, Type: name, Value: cv, Other: other,
+			Message: fmt.Sprintf(msg, args...),
+		})
+	}
+
+	var seen []*ConstValue
+	for _, cv := range consts.Values {
+		if cv.Name.Name == "_" {
+			continue
+		}
+		if cv.Name.PackagePath != name.PackagePath {
+			add(ConstForeign, cv, nil, "%s is declared outside the package of %s", cv.Name.Name, name)
+		}
+		if token.IsExported(name.Name) && !token.IsExported(cv.Name.Name) {
+			add(ConstUnexported, cv, nil, "%s is an unexported value of the exported type %s", cv.Name.Name, name)
+		}
+		for _, prev := range seen {
+			if constant.Compare(prev.Value, token.EQL, cv.Value) {
+				add(ConstDuplicate, cv, prev, "%s has the same value as %s (%s)", cv.Name.Name, prev.Name.Name, cv.Value)
+				break
+			}
+		}
+		seen = append(seen, cv)
+
+		if first, ok := iotaGap(cv); ok {
+			add(ConstGap, cv, nil, "iota values %d to %d before %s are not used by %s",
+				first, cv.IotaIndex-1, cv.Name.Name, name)
+		}
+	}
+	return issues, nil
+}
+
+// iotaGap checks if cv uses iota and the specs before it in its const block
+// only declare blank constants, returning the first unused iota value. Blank
+// specs at the start of the block, like the common "_ = iota", are not gaps.
+func iotaGap(cv *ConstValue) (first int, ok bool) {
+	if !cv.Iota || cv.decl == nil {
+		return 0, false
+	}
+	for i := cv.IotaIndex - 1; i >= 0; i-- {
+		spec, ok := cv.decl.Specs[i].(*ast.ValueSpec)
+		if !ok || !isBlankSpec(spec) {
+			return i + 1, i+1 < cv.IotaIndex
+		}
+	}
+	return 0, false
+}
+
+func isBlankSpec(spec *ast.ValueSpec) bool {
+	for _, name := range spec.Names {
+		if name.Name != "_" {
+			return false
+		}
+	}
+	return true
+}
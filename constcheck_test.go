@@ -0,0 +1,46 @@
+package structer
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestCheckConsts(t *testing.T) {
+	pkg := "github.com/shabbyrobe/structer/testpkg/constcheck"
+	tpset := NewTypePackageSet()
+	if _, err := tpset.ImportPattern(pkg + "/..."); err != nil {
+		t.Fatal(err)
+	}
+
+	issues, err := tpset.CheckConsts(NewTypeName(pkg, "Status"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out []string
+	for _, issue := range issues {
+		out = append(out, fmt.Sprintf("%d %s %s", issue.Value.Position.Line, issue.Kind, issue.Message))
+	}
+	expected := []string{
+		"10 gap iota values 2 to 3 before StatusClosed are not used by " + pkg + ".Status",
+		"11 unexported statusHidden is an unexported value of the exported type " + pkg + ".Status",
+		"13 duplicate StatusOpen has the same value as StatusActive (1)",
+		"5 foreign StatusArchived is declared outside the package of " + pkg + ".Status",
+	}
+	if !reflect.DeepEqual(expected, out) {
+		t.Fatalf("%q", out)
+	}
+	if issues[2].Other == nil || issues[2].Other.Name.Name != "StatusActive" {
+		t.Fatal(issues[2].Other)
+	}
+
+	for _, typ := range []string{"Kind", "Mixed"} {
+		issues, err = tpset.CheckConsts(NewTypeName(pkg, typ))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(issues) != 0 {
+			t.Fatal(typ, issues)
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package structer
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Ident renders a reference to the constant from code in the package
+// imports.PackagePath, i.e. "Red" in the constant's own package, or
+// "colors.Red" elsewhere. The constant's package is added to imports if
+// necessary. It fails if the constant can't be referred to from the package.
+func (cv *ConstValue) Ident(imports *ImportNames) (string, error) {
+	if cv.Const == nil {
+		return "", fmt.Errorf("%s has no types.Const", cv.Name)
+	}
+	name, pkg := cv.Const.Name(), cv.Const.Pkg()
+	if name == "_" {
+		return "", fmt.Errorf("%s is blank", cv.Name)
+	}
+	if pkg == nil || pkg.Path() == imports.PackagePath {
+		return name, nil
+	}
+	if !cv.Const.Exported() {
+		return "", fmt.Errorf("%s is not exported from %s", name, pkg.Path())
+	}
+	return imports.Qualifier(pkg) + "." + name, nil
+}
+
+// Literal renders the value of the constant as a literal converted to the
+// constant's type, for code in the package imports.PackagePath, i.e.
+// `colors.Color("red")`. See ConstLiteral.
+func (cv *ConstValue) Literal(imports *ImportNames) (string, error) {
+	if cv.Const == nil {
+		return ConstLiteral(cv.Value, nil, imports.Qualifier)
+	}
+	if named, ok := cv.Const.Type().(*types.Named); ok {
+		obj := named.Obj()
+		if pkg := obj.Pkg(); pkg != nil && pkg.Path() != imports.PackagePath && !obj.Exported() {
+			return "", fmt.Errorf("type %s of %s is not exported from %s", obj.Name(), cv.Name, pkg.Path())
+		}
+	}
+	return ConstLiteral(cv.Value, cv.Const.Type(), imports.Qualifier)
+}
+
+// Native converts the value of the constant to the Go value it would have at
+// runtime. See ConstNative.
+func (cv *ConstValue) Native() (interface{}, error) {
+	var typ types.Type
+	if cv.Const != nil {
+		typ = cv.Const.Type()
+	}
+	return ConstNative(cv.Value, typ)
+}
+
+// ConstLiteral renders v as a Go expression. If typ is a typed type, the
+// literal is converted to it, i.e. `Level(-1)` or `float32(0.1)`, with
+// packages named by qualifier. If typ is nil or untyped, the literal is
+// untyped.
+//
+// The expression always has exactly the value of v. Strings are quoted,
+// integers of any size are rendered in full, and floats that can't be
+// represented exactly by their type's precision are rendered as a division,
+// i.e. `(1.0 / 3)`. Complex numbers use the complex builtin.
+//
+func ConstLiteral(v constant.Value, typ types.Type, qualifier types.Qualifier) (string, error) {
+	bits := 64
+	typed := false
+	if typ != nil {
+		basic, ok := typ.Underlying().(*types.Basic)
+		if !ok {
+			return "", fmt.Errorf("constant type %s is not a basic type", typ)
+		}
+		typed = basic.Info()&types.IsUntyped == 0
+		if k := basic.Kind(); k == types.Float32 || k == types.Complex64 {
+			bits = 32
+		}
+	}
+
+	var lit string
+	switch v.Kind() {
+	case constant.Bool:
+		lit = strconv.FormatBool(constant.BoolVal(v))
+	case constant.String:
+		lit = strconv.Quote(constant.StringVal(v))
+	case constant.Int:
+		lit = v.ExactString()
+	case constant.Float:
+		lit = floatLiteral(v, bits)
+	case constant.Complex:
+		lit = fmt.Sprintf("complex(%s, %s)", floatLiteral(constant.Real(v), bits), floatLiteral(constant.Imag(v), bits))
+	default:
+		return "", fmt.Errorf("unknown constant value %s", v)
+	}
+
+	if typed {
+		if strings.HasPrefix(lit, "(") {
+			lit = lit[1 : len(lit)-1]
+		}
+		return fmt.Sprintf("%s(%s)", types.TypeString(typ, qualifier), lit), nil
+	}
+	return lit, nil
+}
+
+// floatLiteral renders v as a floating point literal, using the shortest
+// representation that is exact at the given precision. Values that need
+// more precision are rendered as a parenthesised division of integers.
+func floatLiteral(v constant.Value, bits int) string {
+	v = constant.ToFloat(v)
+	if f, exact := constant.Float64Val(v); exact && !math.IsInf(f, 0) {
+		s := strconv.FormatFloat(f, 'g', -1, bits)
+		if !strings.ContainsAny(s, ".e") {
+			s += ".0"
+		}
+		return s
+	}
+	num, denom := constant.Num(v), constant.Denom(v)
+	if constant.Compare(denom, token.EQL, constant.MakeInt64(1)) {
+		return num.ExactString() + ".0"
+	}
+	return fmt.Sprintf("(%s.0 / %s)", num.ExactString(), denom.ExactString())
+}
+
+// ConstNative converts v to the Go value a constant of type typ would have at
+// runtime, i.e. an int8 for a constant whose underlying type is int8, or a
+// float32 for float32. Named types are not preserved, as they aren't
+// available at runtime.
+//
+// If typ is nil or untyped, integers become an int64 if they fit, otherwise a
+// uint64 or a *big.Int. Floats become a float64 and complex numbers a
+// complex128. It fails if v does not fit in the type.
+//
+func ConstNative(v constant.Value, typ types.Type) (interface{}, error) {
+	kind := types.Invalid
+	if typ != nil {
+		basic, ok := typ.Underlying().(*types.Basic)
+		if !ok {
+			return nil, fmt.Errorf("constant type %s is not a basic type", typ)
+		}
+		if basic.Info()&types.IsUntyped == 0 {
+			kind = basic.Kind()
+		}
+	}
+
+	switch v.Kind() {
+	case constant.Bool:
+		return constant.BoolVal(v), nil
+
+	case constant.String:
+		return constant.StringVal(v), nil
+
+	case constant.Int:
+		if kind == types.Invalid {
+			if i, ok := constant.Int64Val(v); ok {
+				return i, nil
+			}
+			if u, ok := constant.Uint64Val(v); ok {
+				return u, nil
+			}
+			return constant.Val(v).(*big.Int), nil
+		}
+		if native, ok := nativeInt(v, kind); ok {
+			return native, nil
+		}
+		if kind == types.Float32 || kind == types.Float64 || kind == types.Complex64 || kind == types.Complex128 {
+			return nativeFloat(constant.ToFloat(v), kind)
+		}
+
+	case constant.Float:
+		return nativeFloat(v, kind)
+
+	case constant.Complex:
+		re, rerr := nativeFloat(constant.Real(v), types.Float64)
+		im, ierr := nativeFloat(constant.Imag(v), types.Float64)
+		if rerr != nil || ierr != nil {
+			break
+		}
+		c := complex(re.(float64), im.(float64))
+		if kind == types.Complex64 {
+			return complex64(c), nil
+		}
+		return c, nil
+	}
+	return nil, fmt.Errorf("constant %s does not fit in %s", v, typ)
+}
+
+func nativeInt(v constant.Value, kind types.BasicKind) (interface{}, bool) {
+	i, iok := constant.Int64Val(v)
+	u, uok := constant.Uint64Val(v)
+	switch kind {
+	case types.Int:
+		return int(i), iok && int64(int(i)) == i
+	case types.Int8:
+		return int8(i), iok && int64(int8(i)) == i
+	case types.Int16:
+		return int16(i), iok && int64(int16(i)) == i
+	case types.Int32:
+		return int32(i), iok && int64(int32(i)) == i
+	case types.Int64:
+		return i, iok
+	case types.Uint:
+		return uint(u), uok && uint64(uint(u)) == u
+	case types.Uint8:
+		return uint8(u), uok && uint64(uint8(u)) == u
+	case types.Uint16:
+		return uint16(u), uok && uint64(uint16(u)) == u
+	case types.Uint32:
+		return uint32(u), uok && uint64(uint32(u)) == u
+	case types.Uint64:
+		return u, uok
+	case types.Uintptr:
+		return uintptr(u), uok && uint64(uintptr(u)) == u
+	}
+	return nil, false
+}
+
+func nativeFloat(v constant.Value, kind types.BasicKind) (interface{}, error) {
+	if kind == types.Float32 {
+		f, _ := constant.Float32Val(v)
+		if math.IsInf(float64(f), 0) {
+			return nil, fmt.Errorf("constant %s overflows float32", v)
+		}
+		return f, nil
+	}
+	f, _ := constant.Float64Val(v)
+	if math.IsInf(f, 0) {
+		return nil, fmt.Errorf("constant %s overflows float64", v)
+	}
+	switch kind {
+	case types.Complex64:
+		return complex64(complex(f, 0)), nil
+	case types.Complex128:
+		return complex(f, 0), nil
+	}
+	return f, nil
+}
+
+// ConstJSON renders v as JSON. Integers of any size are rendered exactly,
+// floats are rounded to the nearest float64. Complex numbers are not
+// supported.
+func ConstJSON(v constant.Value) ([]byte, error) {
+	switch v.Kind() {
+	case constant.Bool:
+		return json.Marshal(constant.BoolVal(v))
+	case constant.String:
+		return json.Marshal(constant.StringVal(v))
+	case constant.Int:
+		return []byte(v.ExactString()), nil
+	case constant.Float:
+		f, err := nativeFloat(v, types.Float64)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(f)
+	}
+	return nil, fmt.Errorf("constant %s can not be rendered as JSON", v)
+}
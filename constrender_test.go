@@ -0,0 +1,195 @@
+package structer
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"math"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+const constRenderPkg = "github.com/shabbyrobe/structer/testpkg/constrender"
+
+func constRenderObjects(t *testing.T) (*TypePackageSet, []*types.Const) {
+	t.Helper()
+	tpset := NewTypePackageSet()
+	pkg, err := tpset.Import(constRenderPkg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var consts []*types.Const
+	for _, name := range pkg.Scope().Names() {
+		if c, ok := pkg.Scope().Lookup(name).(*types.Const); ok && c.Exported() {
+			consts = append(consts, c)
+		}
+	}
+	return tpset, consts
+}
+
+func TestConstLiteral(t *testing.T) {
+	tpset, consts := constRenderObjects(t)
+
+	imports := NewImportNames("example.com/other")
+	var body bytes.Buffer
+	lits := make(map[string]string)
+	for i, c := range consts {
+		lit, err := ConstLiteral(c.Val(), c.Type(), imports.Qualifier)
+		if err != nil {
+			t.Fatal(c.Name(), err)
+		}
+		lits[c.Name()] = lit
+		fmt.Fprintf(&body, "c%d = %s\n", i, lit)
+	}
+
+	for name, expected := range map[string]string{
+		"LevelLow":   "constrender.Level(-1)",
+		"LevelHigh":  "constrender.Level(1099511627776)",
+		"Half":       "constrender.Ratio(0.5)",
+		"Third":      "constrender.Ratio(0.3333333333333333)",
+		"Whole":      "constrender.Ratio(1.0)",
+		"TenthValue": "constrender.Tenth(0.1)",
+		"Quoted":     `constrender.Name("a \"b\"\n\tcé\x00")`,
+		"WaveValue":  "constrender.Wave(complex(1.5, 2.0))",
+		"ToggleOn":   "constrender.Toggle(true)",
+		"Big":        "1267650600228229401496703205376",
+		"Typed":      "int64(-9000000000)",
+	} {
+		if lits[name] != expected {
+			t.Errorf("%s: expected %s, found %s", name, expected, lits[name])
+		}
+	}
+
+	// Every literal must produce exactly the same typed value when compiled
+	// in another package.
+	src := fmt.Sprintf("package other\n\n%s\nconst (\n%s)\n", imports.Source(), body.String())
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "other.go", src, 0)
+	if err != nil {
+		t.Fatalf("%v\n%s", err, src)
+	}
+	conf := types.Config{Importer: tpset}
+	pkg, err := conf.Check("example.com/other", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatalf("%v\n%s", err, src)
+	}
+	for i, c := range consts {
+		found := pkg.Scope().Lookup(fmt.Sprintf("c%d", i)).(*types.Const)
+		if !constant.Compare(c.Val(), token.EQL, found.Val()) {
+			t.Errorf("%s: expected value %s, found %s", c.Name(), c.Val().ExactString(), found.Val().ExactString())
+		}
+		if !types.Identical(c.Type(), found.Type()) {
+			t.Errorf("%s: expected type %s, found %s", c.Name(), c.Type(), found.Type())
+		}
+	}
+}
+
+func TestConstValueIdent(t *testing.T) {
+	tpset := NewTypePackageSet()
+	if _, err := tpset.Import(constRenderPkg); err != nil {
+		t.Fatal(err)
+	}
+	consts, err := tpset.ExtractConsts(NewTypeName(constRenderPkg, "Level"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	low := consts.Values[0]
+
+	if ident, err := low.Ident(NewImportNames(constRenderPkg)); err != nil || ident != "LevelLow" {
+		t.Fatal(ident, err)
+	}
+
+	imports := NewImportNames("example.com/other")
+	imports.Name("example.com/constrender", "constrender")
+	if ident, err := low.Ident(imports); err != nil || ident != "constrender2.LevelLow" {
+		t.Fatal(ident, err)
+	}
+	if lit, err := low.Literal(imports); err != nil || lit != "constrender2.Level(-1)" {
+		t.Fatal(lit, err)
+	}
+
+	small, err := tpset.ExtractConsts(NewTypeName(constRenderPkg, "small"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := small.Values[0].Ident(imports); err == nil {
+		t.Fatal("expected error for unexported constant")
+	}
+	if _, err := small.Values[0].Literal(imports); err == nil {
+		t.Fatal("expected error for unexported type")
+	}
+	if lit, err := small.Values[0].Literal(NewImportNames(constRenderPkg)); err != nil || lit != "small(255)" {
+		t.Fatal(lit, err)
+	}
+	if native, err := small.Values[0].Native(); err != nil || native != uint8(255) {
+		t.Fatal(native, err)
+	}
+}
+
+func TestConstNative(t *testing.T) {
+	_, consts := constRenderObjects(t)
+	big1 := new(big.Int).Lsh(big.NewInt(1), 100)
+
+	expected := map[string]interface{}{
+		"LevelLow":   int(-1),
+		"LevelHigh":  int(1 << 40),
+		"Half":       float64(0.5),
+		"Third":      float64(1.0 / 3),
+		"Whole":      float64(1),
+		"TenthValue": float32(0.1),
+		"Quoted":     "a \"b\"\n\tcé\x00",
+		"WaveValue":  complex128(1.5 + 2i),
+		"ToggleOn":   true,
+		"Big":        big1,
+		"Pi":         math.Pi,
+		"Typed":      int64(-9000000000),
+	}
+	for _, c := range consts {
+		native, err := ConstNative(c.Val(), c.Type())
+		if c.Name() == "Large" {
+			if err == nil {
+				t.Errorf("expected overflow error for Large, found %v", native)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(c.Name(), err)
+		}
+		if !reflect.DeepEqual(expected[c.Name()], native) {
+			t.Errorf("%s: expected %#v, found %#v", c.Name(), expected[c.Name()], native)
+		}
+	}
+
+	if _, err := ConstNative(constant.MakeInt64(256), types.Typ[types.Uint8]); err == nil {
+		t.Fatal("expected error for overflowing uint8")
+	}
+}
+
+func TestConstJSON(t *testing.T) {
+	for _, tc := range []struct {
+		in  constant.Value
+		out string
+	}{
+		{constant.MakeString("a\"b\n"), `"a\"b\n"`},
+		{constant.MakeBool(true), `true`},
+		{constant.MakeFloat64(0.5), `0.5`},
+		{constant.Shift(constant.MakeInt64(1), token.SHL, 100), `1267650600228229401496703205376`},
+		{constant.MakeInt64(-3), `-3`},
+	} {
+		out, err := ConstJSON(tc.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(out) != tc.out {
+			t.Errorf("expected %s, found %s", tc.out, out)
+		}
+	}
+	if _, err := ConstJSON(constant.MakeImag(constant.MakeInt64(1))); err == nil {
+		t.Fatal("expected error for complex constant")
+	}
+}
@@ -1,7 +1,10 @@
 package structer
 
 import (
+	"go/ast"
 	"go/constant"
+	"go/token"
+	"go/types"
 	"sort"
 )
 
@@ -9,7 +12,14 @@ type Consts struct {
 	Type       TypeName
 	Underlying TypeName
 	IsEnum     bool
-	Values     []*ConstValue
+
+	// IsFlags is true if the type is a set of bit flags according to the
+	// EnumPolicy. Use NewFlagSet to decompose values into flags.
+	IsFlags bool
+
+	// Values in the order they were declared. Use SortedValues to order them
+	// by name.
+	Values []*ConstValue
 }
 
 func (e *Consts) SortedValues() []*ConstValue {
@@ -24,6 +34,32 @@ func (e *Consts) SortedValues() []*ConstValue {
 type ConstValue struct {
 	Name  TypeName
 	Value constant.Value
+	Const *types.Const
+
+	// Doc and Comment contain the doc comment for the constant's spec and
+	// the comment following it on the same line. A const declaration that
+	// is not parenthesised provides the Doc if the spec has none.
+	Doc      string
+	Comment  string
+	Position token.Position
+
+	// Directives found in the spec's doc comment and line comment, i.e.
+	// "//structer:name red".
+	Directives Directives
+
+	// Expr is the source of the expression that produced the value. If the
+	// spec has no expression, as in all but the first constant of a typical
+	// iota block, Implicit is true and Expr is the expression repeated from
+	// the previous spec.
+	Expr     string
+	Implicit bool
+
+	// Iota reports whether Expr uses iota. IotaIndex is the value iota had
+	// for the spec, which is the index of the spec in the const declaration.
+	Iota      bool
+	IotaIndex int
+
+	decl *ast.GenDecl
 }
 
 type Enum interface {
@@ -1,7 +1,9 @@
 package structer
 
 import (
+	"fmt"
 	"go/constant"
+	"reflect"
 	"sort"
 	"testing"
 )
@@ -152,3 +154,113 @@ func TestExtractConstsEnum(t *testing.T) {
 		{Name: NewTypeName("github.com/shabbyrobe/structer/testpkg/consts", "TestEnum2"), Value: constant.MakeString("bar")},
 	}, consts)
 }
+
+func constNames(consts *Consts) []string {
+	var names []string
+	for _, v := range consts.SortedValues() {
+		names = append(names, v.Name.String())
+	}
+	return names
+}
+
+func TestExtractConstsAllPackages(t *testing.T) {
+	pkg := "github.com/shabbyrobe/structer/testpkg/constsext"
+	tn := NewTypeName(pkg, "Color")
+
+	tpset := NewTypePackageSet()
+	if _, err := tpset.Import(pkg); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tpset.Import(pkg + "/more"); err != nil {
+		t.Fatal(err)
+	}
+
+	consts, err := tpset.ExtractConsts(tn, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := constNames(consts); !reflect.DeepEqual([]string{pkg + ".Red"}, names) {
+		t.Fatal(names)
+	}
+
+	consts, err = tpset.ExtractConstsWith(tn, ConstsOptions{AllPackages: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := constNames(consts); !reflect.DeepEqual([]string{pkg + ".Red", pkg + "/more.Green"}, names) {
+		t.Fatal(names)
+	}
+}
+
+func TestExtractConstsImportPattern(t *testing.T) {
+	pkg := "github.com/shabbyrobe/structer/testpkg/constsext"
+	tn := NewTypeName(pkg, "Color")
+
+	tpset := NewTypePackageSet()
+	if _, err := tpset.Import(pkg); err != nil {
+		t.Fatal(err)
+	}
+	consts, err := tpset.ExtractConstsWith(tn, ConstsOptions{
+		ImportPattern:     pkg + "/...",
+		IncludeUnexported: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		pkg + ".Red",
+		pkg + ".black",
+		pkg + "/more.Green",
+		pkg + "/more/deeper.Blue",
+		pkg + "/more/deeper.white",
+	}
+	if names := constNames(consts); !reflect.DeepEqual(expected, names) {
+		t.Fatal(names)
+	}
+}
+
+func TestImportPattern(t *testing.T) {
+	pkg := "github.com/shabbyrobe/structer/testpkg/constsext"
+	tpset := NewTypePackageSet()
+	pkgs, err := tpset.ImportPattern(pkg + "/...")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, p := range pkgs {
+		paths = append(paths, p.Path())
+	}
+	if !reflect.DeepEqual([]string{pkg, pkg + "/more", pkg + "/more/deeper"}, paths) {
+		t.Fatal(paths)
+	}
+
+	if _, err := tpset.ImportPattern("github.com/shabbyrobe/structer/testpkg/nope/..."); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestExtractConstsMetadata(t *testing.T) {
+	tn := NewTypeName("github.com/shabbyrobe/structer/testpkg/constsmeta", "Level")
+	consts := extractConsts(t, tn, false)
+
+	var out []string
+	for _, v := range consts.Values {
+		out = append(out, fmt.Sprintf("%s=%s line:%d expr:%q implicit:%v iota:%v/%d doc:%q comment:%q",
+			v.Name.Name, v.Value, v.Position.Line, v.Expr, v.Implicit, v.Iota, v.IotaIndex, v.Doc, v.Comment))
+	}
+	expected := []string{
+		`Warn=3 line:6 expr:"3" implicit:false iota:false/0 doc:"Warn is declared before Debug but has a higher value.\n" comment:""`,
+		`Debug=1 line:12 expr:"iota" implicit:true iota:true/1 doc:"Debug is for debugging.\n" comment:"debug comment\n"`,
+		`Info=2 line:13 expr:"iota" implicit:true iota:true/2 doc:"" comment:""`,
+		`Error=128 line:14 expr:"1 << (iota + 4)" implicit:false iota:true/3 doc:"" comment:""`,
+		`Fatal=256 line:15 expr:"1 << (iota + 4)" implicit:true iota:true/4 doc:"" comment:""`,
+		`Trace=-1 line:18 expr:"-1" implicit:false iota:false/0 doc:"" comment:""`,
+		`Verbose=-2 line:18 expr:"-2" implicit:false iota:false/0 doc:"" comment:""`,
+	}
+	if !reflect.DeepEqual(expected, out) {
+		t.Fatalf("%q", out)
+	}
+	if consts.Values[0].Const == nil || consts.Values[0].Const.Name() != "Warn" {
+		t.Fatal(consts.Values[0].Const)
+	}
+}
@@ -0,0 +1,96 @@
+package structer
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+)
+
+// Directive is a comment of the form "//namespace:name args", like
+// "//go:generate" or "//structer:enum". As with the go tool, there must be no
+// space between the "//" and the namespace. Directives are excluded from the
+// text returned by ast.CommentGroup.Text.
+type Directive struct {
+	Namespace string
+	Name      string
+	Args      string
+	Pos       token.Pos
+}
+
+func (d Directive) String() string {
+	s := "//" + d.Namespace + ":" + d.Name
+	if d.Args != "" {
+		s += " " + d.Args
+	}
+	return s
+}
+
+// Directives is a list of directives in the order they appear in the source.
+type Directives []Directive
+
+// Lookup returns the first directive with the given namespace and name.
+func (d Directives) Lookup(namespace, name string) (Directive, bool) {
+	for _, dir := range d {
+		if dir.Namespace == namespace && dir.Name == name {
+			return dir, true
+		}
+	}
+	return Directive{}, false
+}
+
+// Namespace returns all of the directives in namespace.
+func (d Directives) Namespace(namespace string) Directives {
+	var out Directives
+	for _, dir := range d {
+		if dir.Namespace == namespace {
+			out = append(out, dir)
+		}
+	}
+	return out
+}
+
+// ParseDirectives extracts the directives from the comment groups. Nil groups
+// are ignored.
+func ParseDirectives(groups ...*ast.CommentGroup) Directives {
+	var out Directives
+	for _, grp := range groups {
+		if grp == nil {
+			continue
+		}
+		for _, c := range grp.List {
+			if dir, ok := parseDirective(c); ok {
+				out = append(out, dir)
+			}
+		}
+	}
+	return out
+}
+
+func parseDirective(c *ast.Comment) (dir Directive, ok bool) {
+	if !strings.HasPrefix(c.Text, "//") {
+		return dir, false
+	}
+	text := c.Text[2:]
+
+	colon := strings.IndexByte(text, ':')
+	if colon <= 0 {
+		return dir, false
+	}
+	for _, r := range text[:colon] {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9') {
+			return dir, false
+		}
+	}
+	rest := text[colon+1:]
+	if rest == "" || !(rest[0] >= 'a' && rest[0] <= 'z' || rest[0] >= 'A' && rest[0] <= 'Z') {
+		return dir, false
+	}
+
+	dir.Namespace, dir.Pos = text[:colon], c.Pos()
+	if sp := strings.IndexAny(rest, " \t"); sp >= 0 {
+		dir.Name, dir.Args = rest[:sp], strings.TrimSpace(rest[sp+1:])
+	} else {
+		dir.Name = rest
+	}
+	return dir, true
+}
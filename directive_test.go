@@ -0,0 +1,77 @@
+package structer
+
+import (
+	"fmt"
+	"go/ast"
+	"reflect"
+	"testing"
+)
+
+func TestParseDirectives(t *testing.T) {
+	grp := &ast.CommentGroup{List: []*ast.Comment{
+		{Text: "// Doc comment"},
+		{Text: "//structer:enum"},
+		{Text: "//go:generate stringer -type=Foo"},
+		{Text: "// structer:nope"},
+		{Text: "//https://example.com"},
+		{Text: "//Foo:bar"},
+		{Text: "/* block */"},
+	}}
+	dirs := ParseDirectives(nil, grp)
+
+	var out []string
+	for _, d := range dirs {
+		out = append(out, fmt.Sprintf("%s|%s|%s", d.Namespace, d.Name, d.Args))
+	}
+	expected := []string{
+		"structer|enum|",
+		"go|generate|stringer -type=Foo",
+	}
+	if !reflect.DeepEqual(expected, out) {
+		t.Fatal(out)
+	}
+	if d, ok := dirs.Lookup("go", "generate"); !ok || d.String() != "//go:generate stringer -type=Foo" {
+		t.Fatal(d)
+	}
+	if _, ok := dirs.Lookup("go", "build"); ok {
+		t.Fatal()
+	}
+	if len(dirs.Namespace("structer")) != 1 {
+		t.Fatal(dirs.Namespace("structer"))
+	}
+}
+
+func TestWalkFieldDirectives(t *testing.T) {
+	pkg := "github.com/shabbyrobe/structer/testpkg/directive"
+	tpset := NewTypePackageSet()
+	if _, err := tpset.Import(pkg); err != nil {
+		t.Fatal(err)
+	}
+	tn := NewTypeName(pkg, "Directive")
+	typ := tpset.MustFindObject(tn).Type().Underlying()
+
+	var out []string
+	vis := &PartialTypeVisitor{
+		EnterFieldFunc: func(ctx WalkContext, s StructInfo, field FieldInfo, tag StructTag) error {
+			var dirs []string
+			for _, d := range field.Directives {
+				dirs = append(dirs, d.String())
+			}
+			out = append(out, fmt.Sprintf("%s %q %q %q", field.Name(), field.Doc, field.Comment, dirs))
+			return nil
+		},
+	}
+	if err := Walk(tn, typ, vis, WalkTypePackageSet(tpset)); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		`Name "Name is the name.\n" "trailing\n" ["//structer:validate required"]`,
+		`Nested "" "" []`,
+		`Deep "Deep is nested.\n" "" ["//structer:skip" "//go:generate echo nope" "//structer:default 1"]`,
+		`Plain "Plain has no directives.\nhttps://example.com is not a directive.\n" "" []`,
+	}
+	if !reflect.DeepEqual(expected, out) {
+		t.Fatalf("%q", out)
+	}
+}
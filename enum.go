@@ -0,0 +1,138 @@
+package structer
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"strings"
+)
+
+// EnumPolicy decides which named types are enums, i.e. types whose constants
+// are assumed to be the complete set of values, rather than just a bag of
+// constants. A type is an enum if any of the enabled checks match.
+type EnumPolicy struct {
+	// MarkerMethods contains the names of methods that mark a type as an
+	// enum. The method must take no arguments and return nothing, like
+	// Enum.IsEnum.
+	MarkerMethods []string
+
+	// Directive is a directive, in the form "namespace:name", that marks a
+	// type as an enum if it appears in the type's doc comment or on the
+	// same line as the declaration, i.e. "structer:enum" matches:
+	//
+	//	//structer:enum
+	//	type Color int
+	//
+	Directive string
+
+	// IotaBlock treats a type as an enum if all of the constants declared in
+	// its package (including unexported ones) are declared in a single const
+	// block and all use iota.
+	IotaBlock bool
+
+	// FlagsDirective is a directive that marks an integer type as a set of
+	// bit flags, in the same form as Directive, i.e. "structer:flags".
+	FlagsDirective string
+
+	// PowerOfTwoFlags treats an integer type as a set of bit flags if at
+	// least three of its constants have a single bit set, and the rest are
+	// zero or combinations of those bits declared after all of the flags.
+	// Types that are enums according to the other checks are never treated
+	// as flags this way; use FlagsDirective to mark them.
+	PowerOfTwoFlags bool
+}
+
+// DefaultEnumPolicy recognises types that implement Enum and types marked
+// with a "//structer:enum" directive. Types marked with "//structer:flags",
+// or with constants that look like bit flags, are recognised as flag sets.
+var DefaultEnumPolicy = EnumPolicy{
+	MarkerMethods:   []string{"IsEnum"},
+	Directive:       "structer:enum",
+	FlagsDirective:  "structer:flags",
+	PowerOfTwoFlags: true,
+}
+
+// WithEnumPolicy sets the TypePackageSet's EnumPolicy.
+func WithEnumPolicy(policy EnumPolicy) option {
+	return func(t *TypePackageSet) {
+		t.EnumPolicy = policy
+	}
+}
+
+// IsEnum reports whether the type name is an enum according to the
+// TypePackageSet's EnumPolicy.
+func (t *TypePackageSet) IsEnum(name TypeName) (bool, error) {
+	def := t.Objects[name]
+	if def == nil {
+		return false, fmt.Errorf("could not find def for %s", name)
+	}
+	named, ok := def.Type().(*types.Named)
+	if !ok {
+		return false, fmt.Errorf("type %s must be *types.Named, found %T", name, def.Type())
+	}
+	return t.isEnum(name, def, named), nil
+}
+
+func (t *TypePackageSet) isEnum(name TypeName, def types.Object, named *types.Named) bool {
+	policy := t.EnumPolicy
+	for _, method := range policy.MarkerMethods {
+		if hasMarkerMethod(def, named, method) {
+			return true
+		}
+	}
+	if policy.Directive != "" && t.hasTypeDirective(def, policy.Directive) {
+		return true
+	}
+	if policy.IotaBlock && t.isIotaBlock(name) {
+		return true
+	}
+	return false
+}
+
+// hasMarkerMethod checks if the type has a method called method that takes no
+// arguments and returns nothing.
+func hasMarkerMethod(def types.Object, named *types.Named, method string) bool {
+	mset := types.NewMethodSet(named)
+	sel := mset.Lookup(def.Pkg(), method)
+	if sel != nil && sel.Kind() == types.MethodVal {
+		sig := sel.Type().(*types.Signature)
+		return sig.Params().Len() == 0 && sig.Results().Len() == 0
+	}
+	return false
+}
+
+func (t *TypePackageSet) hasTypeDirective(def types.Object, directive string) bool {
+	namespace, dname := directive, ""
+	if idx := strings.IndexByte(directive, ':'); idx >= 0 {
+		namespace, dname = directive[:idx], directive[idx+1:]
+	}
+	if def.Pkg() == nil {
+		return false
+	}
+	asts := t.ASTPackages
+	ts, ok := asts.FindNodeByPackagePathPos(def.Pkg().Path(), def.Pos()).(*ast.TypeSpec)
+	if !ok {
+		return false
+	}
+	groups := []*ast.CommentGroup{ts.Doc, ts.Comment}
+	if gd := asts.GenDecls[ts]; gd != nil && !gd.Lparen.IsValid() {
+		groups = append(groups, gd.Doc)
+	}
+	_, found := ParseDirectives(groups...).Lookup(namespace, dname)
+	return found
+}
+
+// isIotaBlock checks if all of the constants of the type name in its own
+// package are declared in the same const block using iota.
+func (t *TypePackageSet) isIotaBlock(name TypeName) bool {
+	values := t.packageConsts(name.PackagePath, name, true)
+	if len(values) == 0 {
+		return false
+	}
+	for _, v := range values {
+		if !v.Iota || v.decl != values[0].decl {
+			return false
+		}
+	}
+	return true
+}
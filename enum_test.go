@@ -0,0 +1,67 @@
+package structer
+
+import (
+	"testing"
+)
+
+func TestEnumPolicy(t *testing.T) {
+	pkg := "github.com/shabbyrobe/structer/testpkg/enums"
+
+	policies := []struct {
+		name   string
+		policy EnumPolicy
+		enums  map[string]bool
+	}{
+		{"default", DefaultEnumPolicy, map[string]bool{
+			"Directive": true,
+			"Trailing":  true,
+		}},
+		{"none", EnumPolicy{}, map[string]bool{}},
+		{"marker", EnumPolicy{MarkerMethods: []string{"IsDomainEnum"}}, map[string]bool{
+			"Marker": true,
+		}},
+		{"iota", EnumPolicy{IotaBlock: true}, map[string]bool{
+			"Marker": true,
+			"Iota":   true,
+		}},
+	}
+
+	names := []string{"Directive", "Trailing", "Marker", "Iota", "SplitIota", "MixedIota", "NoConsts"}
+	for _, p := range policies {
+		tpset := NewTypePackageSet(WithEnumPolicy(p.policy))
+		if _, err := tpset.Import(pkg); err != nil {
+			t.Fatal(err)
+		}
+		for _, typ := range names {
+			tn := NewTypeName(pkg, typ)
+			is, err := tpset.IsEnum(tn)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if is != p.enums[typ] {
+				t.Errorf("policy %s: expected IsEnum(%s) to be %v", p.name, typ, p.enums[typ])
+			}
+			consts, err := tpset.ExtractConsts(tn, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if consts.IsEnum != is {
+				t.Errorf("policy %s: ExtractConsts(%s).IsEnum disagrees with IsEnum", p.name, typ)
+			}
+		}
+	}
+}
+
+func TestEnumPolicyConstsEnum(t *testing.T) {
+	tpset := NewTypePackageSet()
+	tn := NewTypeName("github.com/shabbyrobe/structer/testpkg/consts", "TestEnum")
+	if _, err := tpset.Import(tn.PackagePath); err != nil {
+		t.Fatal(err)
+	}
+	if is, err := tpset.IsEnum(tn); err != nil || !is {
+		t.Fatal(is, err)
+	}
+	if _, err := tpset.IsEnum(NewTypeName(tn.PackagePath, "Nope")); err == nil {
+		t.Fatal("expected error")
+	}
+}
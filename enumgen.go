@@ -0,0 +1,352 @@
+package structer
+
+import (
+	"bytes"
+	"fmt"
+	"go/constant"
+	"go/format"
+	"go/token"
+	"go/types"
+	"strconv"
+	"strings"
+)
+
+// EnumGenOptions controls GenerateEnums.
+type EnumGenOptions struct {
+	// IncludeUnexported includes unexported constants in the generated
+	// methods.
+	IncludeUnexported bool
+
+	// TrimPrefix removes the type's name from the start of the constant
+	// names used as names for integer enums, i.e. "ColorRed" becomes "Red".
+	TrimPrefix bool
+
+	// LineComment uses the comment following a constant on the same line as
+	// its name, like stringer's -linecomment flag.
+	LineComment bool
+
+	// NameDirective is the directive used to override the name of a single
+	// constant, i.e. "//structer:name red". It defaults to "structer:name".
+	NameDirective string
+}
+
+// EnumTypes returns the names of the types in the package pkgPath that are
+// enums or flag sets according to the EnumPolicy, sorted by name, i.e. the
+// types GenerateEnums accepts.
+func (t *TypePackageSet) EnumTypes(pkgPath string) TypeNames {
+	var names TypeNames
+	for name, obj := range t.Objects {
+		if name.PackagePath != pkgPath {
+			continue
+		}
+		if _, ok := obj.(*types.TypeName); !ok {
+			continue
+		}
+		named, ok := obj.Type().(*types.Named)
+		if !ok {
+			continue
+		}
+		if t.isEnum(name, obj, named) || t.isFlags(name, obj) {
+			names = append(names, name)
+		}
+	}
+	names.Sort()
+	return names
+}
+
+// GenerateEnums generates helpers for each of the enum types, which must all
+// be declared in the same package, and returns the source of a file in that
+// package that contains them. For an enum called Color, the helpers are:
+//
+//	func (v Color) String() string
+//	func (v Color) IsValid() bool
+//	func (v Color) MarshalText() ([]byte, error)
+//	func (v *Color) UnmarshalText(b []byte) error
+//	func (v Color) MarshalJSON() ([]byte, error)
+//	func (v *Color) UnmarshalJSON(b []byte) error
+//	func ParseColor(s string) (Color, error)
+//	func ColorValues() []Color
+//
+// The name of each value is the value itself for string enums, and the
+// constant's name for integer enums. Either can be overridden using the
+// NameDirective or LineComment options. If several constants have the same
+// value, String returns the name of the first one and Parse accepts all of
+// them.
+//
+// Flag sets (see Consts.IsFlags) are also accepted, including enums marked
+// with the EnumPolicy's FlagsDirective. Their String method joins the names
+// of the flags that are set with "|", i.e. "Read|Write", Parse accepts the
+// same format and XValues returns each single flag.
+//
+func (t *TypePackageSet) GenerateEnums(opts EnumGenOptions, names ...TypeName) ([]byte, error) {
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no enum types")
+	}
+	if opts.NameDirective == "" {
+		opts.NameDirective = "structer:name"
+	}
+
+	var body bytes.Buffer
+	var needStrings bool
+	pkgPath := names[0].PackagePath
+	for _, name := range names {
+		if name.PackagePath != pkgPath {
+			return nil, fmt.Errorf("enum %s is not in package %s", name, pkgPath)
+		}
+		enum, err := t.enumGenValues(name, opts)
+		if err != nil {
+			return nil, err
+		}
+		enum.write(&body)
+		needStrings = needStrings || enum.flags
+	}
+
+	pkg := t.TypePackages[pkgPath]
+	if pkg == nil {
+		return nil, fmt.Errorf("package %s not imported", pkgPath)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by structer. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", pkg.Name())
+	buf.WriteString("import (\n\"encoding/json\"\n\"fmt\"\n")
+	if needStrings {
+		buf.WriteString("\"strings\"\n")
+	}
+	buf.WriteString(")\n")
+	buf.Write(body.Bytes())
+	return format.Source(buf.Bytes())
+}
+
+type enumGen struct {
+	name   string
+	basic  *types.Basic
+	values []enumGenValue
+
+	// unique contains the first value for each distinct constant value. For
+	// flag sets, it only contains the single bit flags.
+	unique []enumGenValue
+
+	// flags is true if the type is a flag set. zero is the value for 0, if
+	// there is one.
+	flags bool
+	zero  *enumGenValue
+}
+
+type enumGenValue struct {
+	ident string
+	name  string
+	value constant.Value
+}
+
+func (t *TypePackageSet) enumGenValues(name TypeName, opts EnumGenOptions) (*enumGen, error) {
+	consts, err := t.ExtractConsts(name, opts.IncludeUnexported)
+	if err != nil {
+		return nil, err
+	}
+	if !consts.IsEnum && !consts.IsFlags {
+		return nil, fmt.Errorf("type %s is not an enum", name)
+	}
+	var flags *FlagSet
+	if consts.IsFlags {
+		if flags, err = NewFlagSet(consts); err != nil {
+			return nil, err
+		}
+	}
+	basic, ok := t.Objects[name].Type().Underlying().(*types.Basic)
+	if !ok || basic.Info()&(types.IsString|types.IsInteger) == 0 {
+		return nil, fmt.Errorf("enum %s must have a string or integer underlying type", name)
+	}
+
+	nsName := opts.NameDirective
+	var ns string
+	if idx := strings.IndexByte(nsName, ':'); idx >= 0 {
+		ns, nsName = nsName[:idx], nsName[idx+1:]
+	}
+
+	enum := &enumGen{name: name.Name, basic: basic, flags: flags != nil}
+	byName := make(map[string]constant.Value)
+	for _, cv := range consts.Values {
+		if cv.Name.Name == "_" {
+			continue
+		}
+		v := enumGenValue{ident: cv.Name.Name, value: cv.Value}
+		if dir, ok := cv.Directives.Lookup(ns, nsName); ok && dir.Args != "" {
+			v.name = dir.Args
+		} else if comment := strings.TrimSpace(cv.Comment); opts.LineComment && comment != "" {
+			v.name = comment
+		} else if basic.Info()&types.IsString != 0 {
+			v.name = constant.StringVal(cv.Value)
+		} else if opts.TrimPrefix && strings.HasPrefix(v.ident, name.Name) && v.ident != name.Name {
+			v.name = strings.TrimPrefix(v.ident, name.Name)
+		} else {
+			v.name = v.ident
+		}
+
+		if prev, ok := byName[v.name]; ok && !constant.Compare(prev, token.EQL, v.value) {
+			return nil, fmt.Errorf("enum %s has more than one value called %q", name, v.name)
+		}
+		byName[v.name] = v.value
+
+		enum.values = append(enum.values, v)
+		switch {
+		case flags != nil && flags.Zero == cv:
+			zero := v
+			enum.zero = &zero
+		case flags != nil && flags.find(cv.Value) != cv:
+			// Masks and duplicate flags are only accepted by Parse.
+		case enum.find(v.value) == nil:
+			enum.unique = append(enum.unique, v)
+		}
+	}
+	if len(enum.unique) == 0 {
+		return nil, fmt.Errorf("enum %s has no values", name)
+	}
+	return enum, nil
+}
+
+func (e *enumGen) find(value constant.Value) *enumGenValue {
+	for i := range e.unique {
+		if constant.Compare(e.unique[i].value, token.EQL, value) {
+			return &e.unique[i]
+		}
+	}
+	return nil
+}
+
+// funcName returns the name of a helper function for the enum, i.e.
+// "ParseColor" for the prefix "Parse". If the enum is unexported, so is the
+// function.
+func (e *enumGen) funcName(prefix, suffix string) string {
+	name := e.name
+	if prefix != "" {
+		if !token.IsExported(e.name) {
+			prefix = strings.ToLower(prefix[:1]) + prefix[1:]
+		}
+		name = prefix + upperFirst(name)
+	}
+	return name + suffix
+}
+
+func (e *enumGen) write(w *bytes.Buffer) {
+	if e.flags {
+		e.writeFlags(w)
+	} else {
+		e.writeEnum(w)
+	}
+	e.writeMarshal(w)
+}
+
+func (e *enumGen) idents() []string {
+	var idents []string
+	for _, v := range e.unique {
+		idents = append(idents, v.ident)
+	}
+	return idents
+}
+
+// writeParseCases writes a case for each name accepted by Parse, calling
+// stmt with the constant's identifier to produce the case body.
+func (e *enumGen) writeParseCases(w *bytes.Buffer, stmt func(ident string) string) {
+	seen := make(map[string]bool)
+	for _, v := range e.values {
+		if seen[v.name] {
+			continue
+		}
+		seen[v.name] = true
+		fmt.Fprintf(w, "\tcase %s:\n\t\t%s\n", strconv.Quote(v.name), stmt(v.ident))
+	}
+}
+
+func (e *enumGen) writeValues(w *bytes.Buffer, desc string) {
+	name, values := e.name, e.funcName("", "Values")
+	fmt.Fprintf(w, "\n// %s returns each %s of %s in declaration order.\n", values, desc, name)
+	fmt.Fprintf(w, "func %s() []%s {\n\treturn []%s{%s}\n}\n", values, name, name, strings.Join(e.idents(), ", "))
+}
+
+func (e *enumGen) writeEnum(w *bytes.Buffer) {
+	name := e.name
+	parse := e.funcName("Parse", "")
+
+	fmt.Fprintf(w, "\n// String returns the name of the %s.\n", name)
+	fmt.Fprintf(w, "func (v %s) String() string {\n\tswitch v {\n", name)
+	for _, v := range e.unique {
+		fmt.Fprintf(w, "\tcase %s:\n\t\treturn %s\n", v.ident, strconv.Quote(v.name))
+	}
+	w.WriteString("\t}\n")
+	switch {
+	case e.basic.Info()&types.IsString != 0:
+		fmt.Fprintf(w, "\treturn fmt.Sprintf(\"%s(%%q)\", string(v))\n}\n", name)
+	case e.basic.Info()&types.IsUnsigned != 0:
+		fmt.Fprintf(w, "\treturn fmt.Sprintf(\"%s(%%d)\", uint64(v))\n}\n", name)
+	default:
+		fmt.Fprintf(w, "\treturn fmt.Sprintf(\"%s(%%d)\", int64(v))\n}\n", name)
+	}
+
+	fmt.Fprintf(w, "\n// IsValid reports whether v is one of the declared values of %s.\n", name)
+	fmt.Fprintf(w, "func (v %s) IsValid() bool {\n\tswitch v {\n\tcase %s:\n\t\treturn true\n\t}\n\treturn false\n}\n",
+		name, strings.Join(e.idents(), ", "))
+
+	fmt.Fprintf(w, "\n// %s converts a name returned by %s.String into a %s.\n", parse, name, name)
+	fmt.Fprintf(w, "func %s(s string) (%s, error) {\n\tswitch s {\n", parse, name)
+	e.writeParseCases(w, func(ident string) string { return "return " + ident + ", nil" })
+	fmt.Fprintf(w, "\t}\n\tvar zero %s\n\treturn zero, fmt.Errorf(\"invalid %s %%q\", s)\n}\n", name, name)
+
+	e.writeValues(w, "distinct value")
+}
+
+func (e *enumGen) writeFlags(w *bytes.Buffer) {
+	name := e.name
+	parse := e.funcName("Parse", "")
+	all := strings.Join(e.idents(), " | ")
+	zero := "0"
+	if e.zero != nil {
+		zero = e.zero.name
+	}
+
+	fmt.Fprintf(w, "\n// String returns the names of the flags set in the %s, separated by \"|\".\n", name)
+	fmt.Fprintf(w, "func (v %s) String() string {\n", name)
+	fmt.Fprintf(w, "\tif v == 0 {\n\t\treturn %s\n\t}\n\tvar names []string\n", strconv.Quote(zero))
+	for _, v := range e.unique {
+		fmt.Fprintf(w, "\tif v&%s != 0 {\n\t\tnames = append(names, %s)\n\t}\n", v.ident, strconv.Quote(v.name))
+	}
+	fmt.Fprintf(w, "\tif rest := v &^ (%s); rest != 0 {\n", all)
+	w.WriteString("\t\tnames = append(names, fmt.Sprintf(\"0x%x\", uint64(rest)))\n\t}\n")
+	w.WriteString("\treturn strings.Join(names, \"|\")\n}\n")
+
+	fmt.Fprintf(w, "\n// IsValid reports whether v only contains the declared flags of %s.\n", name)
+	fmt.Fprintf(w, "func (v %s) IsValid() bool {\n\treturn v&^(%s) == 0\n}\n", name, all)
+
+	fmt.Fprintf(w, "\n// %s converts a string returned by %s.String into a %s.\n", parse, name, name)
+	fmt.Fprintf(w, "func %s(s string) (%s, error) {\n\tvar v %s\n", parse, name, name)
+	w.WriteString("\tfor _, name := range strings.Split(s, \"|\") {\n\t\tswitch name {\n")
+	if e.zero == nil {
+		w.WriteString("\tcase \"0\":\n")
+	}
+	e.writeParseCases(w, func(ident string) string { return "v |= " + ident })
+	fmt.Fprintf(w, "\t\tdefault:\n\t\t\treturn 0, fmt.Errorf(\"invalid %s %%q\", s)\n\t\t}\n\t}\n\treturn v, nil\n}\n", name)
+
+	e.writeValues(w, "flag")
+}
+
+func (e *enumGen) writeMarshal(w *bytes.Buffer) {
+	name := e.name
+	parse := e.funcName("Parse", "")
+
+	fmt.Fprintf(w, "\n// MarshalText implements encoding.TextMarshaler.\n")
+	fmt.Fprintf(w, "func (v %s) MarshalText() ([]byte, error) {\n", name)
+	fmt.Fprintf(w, "\tif !v.IsValid() {\n\t\treturn nil, fmt.Errorf(\"invalid %s %%s\", v)\n\t}\n", name)
+	w.WriteString("\treturn []byte(v.String()), nil\n}\n")
+
+	fmt.Fprintf(w, "\n// UnmarshalText implements encoding.TextUnmarshaler.\n")
+	fmt.Fprintf(w, "func (v *%s) UnmarshalText(b []byte) error {\n", name)
+	fmt.Fprintf(w, "\tparsed, err := %s(string(b))\n\tif err != nil {\n\t\treturn err\n\t}\n\t*v = parsed\n\treturn nil\n}\n", parse)
+
+	fmt.Fprintf(w, "\n// MarshalJSON implements json.Marshaler.\n")
+	fmt.Fprintf(w, "func (v %s) MarshalJSON() ([]byte, error) {\n", name)
+	w.WriteString("\ttext, err := v.MarshalText()\n\tif err != nil {\n\t\treturn nil, err\n\t}\n\treturn json.Marshal(string(text))\n}\n")
+
+	fmt.Fprintf(w, "\n// UnmarshalJSON implements json.Unmarshaler.\n")
+	fmt.Fprintf(w, "func (v *%s) UnmarshalJSON(b []byte) error {\n", name)
+	w.WriteString("\tvar s string\n\tif err := json.Unmarshal(b, &s); err != nil {\n\t\treturn err\n\t}\n\treturn v.UnmarshalText([]byte(s))\n}\n")
+}
@@ -0,0 +1,106 @@
+package structer
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// checkGenerated type checks src alongside the files of the package pkg.
+func checkGenerated(t *testing.T, tpset *TypePackageSet, pkg string, src []byte) {
+	t.Helper()
+	fset := token.NewFileSet()
+	var files []*ast.File
+	astPkg := tpset.ASTPackages.Packages[pkg]
+	for _, name := range tpset.BuiltFiles[pkg] {
+		f, err := parser.ParseFile(fset, name, astPkg.Contents[filepath.Base(name)], 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, f)
+	}
+	gen, err := parser.ParseFile(fset, "generated.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files = append(files, gen)
+
+	conf := types.Config{Importer: importer.Default()}
+	if _, err := conf.Check(pkg, fset, files, nil); err != nil {
+		t.Fatalf("%v\n%s", err, src)
+	}
+}
+
+func TestGenerateEnums(t *testing.T) {
+	pkg := "github.com/shabbyrobe/structer/testpkg/consts"
+	tpset := NewTypePackageSet()
+	if _, err := tpset.Import(pkg); err != nil {
+		t.Fatal(err)
+	}
+
+	names := tpset.EnumTypes(pkg)
+	expected := TypeNames{NewTypeName(pkg, "TestEnum"), NewTypeName(pkg, "TestEnumInt")}
+	if !reflect.DeepEqual(expected, names) {
+		t.Fatal(names)
+	}
+
+	src, err := tpset.GenerateEnums(EnumGenOptions{TrimPrefix: true, LineComment: true}, names...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGenerated(t, tpset, pkg, src)
+
+	out := string(src)
+	for _, frag := range []string{
+		"package consts\n",
+		"func (v TestEnum) String() string {",
+		"case TestEnum1:\n\t\treturn \"foo\"",
+		"return fmt.Sprintf(\"TestEnum(%q)\", string(v))",
+		"func ParseTestEnum(s string) (TestEnum, error) {",
+		"func TestEnumValues() []TestEnum {\n\treturn []TestEnum{TestEnum1, TestEnum2}",
+		"func (v *TestEnum) UnmarshalJSON(b []byte) error {",
+
+		// Names from the directive, the line comment and the trimmed
+		// constant name.
+		"case TestEnumInt1:\n\t\treturn \"one\"",
+		"case TestEnumInt2:\n\t\treturn \"two\"",
+		"case TestEnumInt3:\n\t\treturn \"3\"",
+		"return fmt.Sprintf(\"TestEnumInt(%d)\", int64(v))",
+
+		// The alias is accepted by Parse but not listed as a distinct value.
+		"case \"Default\":\n\t\treturn TestEnumIntDefault, nil",
+		"case TestEnumInt1, TestEnumInt2, TestEnumInt3:\n\t\treturn true",
+		"return []TestEnumInt{TestEnumInt1, TestEnumInt2, TestEnumInt3}",
+	} {
+		if !strings.Contains(out, frag) {
+			t.Errorf("missing %q in:\n%s", frag, out)
+		}
+	}
+}
+
+func TestGenerateEnumsNotEnum(t *testing.T) {
+	pkg := "github.com/shabbyrobe/structer/testpkg/consts"
+	tpset := NewTypePackageSet()
+	if _, err := tpset.Import(pkg); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tpset.GenerateEnums(EnumGenOptions{}, NewTypeName(pkg, "TestIota")); err == nil {
+		t.Fatal("expected error")
+	}
+
+	tpset = NewTypePackageSet(WithEnumPolicy(EnumPolicy{IotaBlock: true}))
+	if _, err := tpset.Import(pkg); err != nil {
+		t.Fatal(err)
+	}
+	src, err := tpset.GenerateEnums(EnumGenOptions{}, NewTypeName(pkg, "TestIota"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGenerated(t, tpset, pkg, src)
+}
@@ -0,0 +1,281 @@
+package structer
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+)
+
+// SwitchOptions controls CheckSwitches.
+type SwitchOptions struct {
+	// Packages to check. If empty, every package imported from source is
+	// checked.
+	Packages []string
+
+	// SealedDirective marks an interface as sealed, i.e. "structer:sealed",
+	// so that type switches over it are checked. Interfaces with unexported
+	// methods are always considered sealed, as they can only be implemented
+	// in their own package. It defaults to "structer:sealed".
+	SealedDirective string
+}
+
+// SwitchIssue is a switch statement found by CheckSwitches that does not
+// handle every value of an enum, or every implementer of a sealed interface,
+// and does not have a default case.
+type SwitchIssue struct {
+	Pos      token.Pos
+	Position token.Position
+
+	// Type is the enum or interface switched over.
+	Type TypeName
+
+	// TypeSwitch is true if the switch is a type switch over an interface.
+	TypeSwitch bool
+
+	// Missing contains the names of the enum's constants, or the
+	// implementers of the interface, that are not handled.
+	Missing []TypeName
+}
+
+func (s SwitchIssue) String() string {
+	missing := make([]string, len(s.Missing))
+	for i, m := range s.Missing {
+		missing[i] = m.Name
+	}
+	kind := "switch"
+	if s.TypeSwitch {
+		kind = "type switch"
+	}
+	return fmt.Sprintf("%s: %s over %s is missing cases: %s", s.Position, kind, s.Type, strings.Join(missing, ", "))
+}
+
+// CheckSwitches finds switch statements in the function bodies of the
+// imported packages that are not exhaustive. A switch over a value whose type
+// is an enum (according to the EnumPolicy) must have a case for every value
+// of the enum, and a type switch over a sealed interface must have a case for
+// every known implementer, unless the switch has a default case.
+//
+// Only the implementers in packages imported into the TypePackageSet are
+// known.
+//
+func (t *TypePackageSet) CheckSwitches(opts SwitchOptions) ([]SwitchIssue, error) {
+	if opts.SealedDirective == "" {
+		opts.SealedDirective = "structer:sealed"
+	}
+	pkgPaths := opts.Packages
+	if len(pkgPaths) == 0 {
+		for pkgPath := range t.Infos {
+			pkgPaths = append(pkgPaths, pkgPath)
+		}
+		sort.Strings(pkgPaths)
+	}
+
+	sc := &switchChecker{tpset: t, opts: opts, consts: make(map[TypeName]*Consts)}
+	for _, pkgPath := range pkgPaths {
+		astPkg := t.ASTPackages.Packages[pkgPath]
+		info, ok := t.Infos[pkgPath]
+		if astPkg == nil || !ok {
+			return nil, fmt.Errorf("package %s not imported", pkgPath)
+		}
+		files := make([]string, 0, len(astPkg.AST.Files))
+		for file := range astPkg.AST.Files {
+			files = append(files, file)
+		}
+		sort.Strings(files)
+
+		for _, file := range files {
+			ast.Inspect(astPkg.AST.Files[file], func(node ast.Node) bool {
+				switch node := node.(type) {
+				case *ast.SwitchStmt:
+					sc.checkSwitch(pkgPath, info, node)
+				case *ast.TypeSwitchStmt:
+					sc.checkTypeSwitch(info, node)
+				}
+				return true
+			})
+		}
+	}
+	return sc.issues, nil
+}
+
+type switchChecker struct {
+	tpset  *TypePackageSet
+	opts   SwitchOptions
+	consts map[TypeName]*Consts
+	issues []SwitchIssue
+}
+
+func (sc *switchChecker) issue(pos token.Pos, tn TypeName, typeSwitch bool, missing []TypeName) {
+	sc.issues = append(sc.issues, SwitchIssue{
+		Pos:        pos,
+		Position:   sc.tpset.ASTPackages.FileSet.Position(pos),
+		Type:       tn,
+		TypeSwitch: typeSwitch,
+		Missing:    missing,
+	})
+}
+
+// switchClauses returns the case clauses of a switch body, or false if one of
+// them is the default case.
+func switchClauses(body *ast.BlockStmt) (clauses []*ast.CaseClause, ok bool) {
+	for _, stmt := range body.List {
+		cc := stmt.(*ast.CaseClause)
+		if cc.List == nil {
+			return nil, false
+		}
+		clauses = append(clauses, cc)
+	}
+	return clauses, true
+}
+
+// enumConsts returns the values of the enum named, or nil if it is not an
+// enum.
+func (sc *switchChecker) enumConsts(named *types.Named) *Consts {
+	if named.Obj().Pkg() == nil {
+		return nil
+	}
+	tn := NewTypeName(named.Obj().Pkg().Path(), named.Obj().Name())
+	if consts, ok := sc.consts[tn]; ok {
+		return consts
+	}
+	var consts *Consts
+	if def := sc.tpset.Objects[tn]; def != nil && sc.tpset.isEnum(tn, def, named) {
+		consts, _ = sc.tpset.ExtractConsts(tn, true)
+	}
+	sc.consts[tn] = consts
+	return consts
+}
+
+func (sc *switchChecker) checkSwitch(pkgPath string, info types.Info, sw *ast.SwitchStmt) {
+	if sw.Tag == nil {
+		return
+	}
+	tv, ok := info.Types[sw.Tag]
+	if !ok {
+		return
+	}
+	named, ok := tv.Type.(*types.Named)
+	if !ok {
+		return
+	}
+	consts := sc.enumConsts(named)
+	if consts == nil || len(consts.Values) == 0 {
+		return
+	}
+	clauses, ok := switchClauses(sw.Body)
+	if !ok {
+		return
+	}
+
+	var handled []constant.Value
+	for _, cc := range clauses {
+		for _, expr := range cc.List {
+			if ctv, ok := info.Types[expr]; ok && ctv.Value != nil {
+				handled = append(handled, ctv.Value)
+			}
+		}
+	}
+
+	// Unexported values can't be named outside the enum's package. Aliases
+	// of a missing value are only reported once.
+	local := named.Obj().Pkg().Path() == pkgPath
+	var missing []TypeName
+	for _, cv := range consts.Values {
+		if cv.Name.Name == "_" || (!local && !token.IsExported(cv.Name.Name)) {
+			continue
+		}
+		if !containsConstant(handled, cv.Value) {
+			missing = append(missing, cv.Name)
+			handled = append(handled, cv.Value)
+		}
+	}
+	if len(missing) > 0 {
+		sc.issue(sw.Pos(), consts.Type, false, missing)
+	}
+}
+
+func (sc *switchChecker) checkTypeSwitch(info types.Info, sw *ast.TypeSwitchStmt) {
+	var ta *ast.TypeAssertExpr
+	switch assign := sw.Assign.(type) {
+	case *ast.AssignStmt:
+		ta, _ = assign.Rhs[0].(*ast.TypeAssertExpr)
+	case *ast.ExprStmt:
+		ta, _ = assign.X.(*ast.TypeAssertExpr)
+	}
+	if ta == nil {
+		return
+	}
+	tv, ok := info.Types[ta.X]
+	if !ok {
+		return
+	}
+	named, ok := tv.Type.(*types.Named)
+	if !ok || !types.IsInterface(named) || !sc.isSealed(named) {
+		return
+	}
+	clauses, ok := switchClauses(sw.Body)
+	if !ok {
+		return
+	}
+
+	var handled []types.Type
+	for _, cc := range clauses {
+		for _, expr := range cc.List {
+			if ctv, ok := info.Types[expr]; ok && ctv.IsType() {
+				handled = append(handled, ctv.Type)
+			}
+		}
+	}
+
+	var missing []TypeName
+	for _, impl := range sc.tpset.ImplementersOf(named) {
+		if !implHandled(impl, handled) {
+			missing = append(missing, impl.Name)
+		}
+	}
+	if len(missing) > 0 {
+		tn := NewTypeName(named.Obj().Pkg().Path(), named.Obj().Name())
+		sc.issue(sw.Pos(), tn, true, missing)
+	}
+}
+
+// isSealed checks if the interface named can only be implemented in its own
+// package, or is marked with the SealedDirective.
+func (sc *switchChecker) isSealed(named *types.Named) bool {
+	iface := named.Underlying().(*types.Interface)
+	for i := 0; i < iface.NumMethods(); i++ {
+		if !iface.Method(i).Exported() {
+			return true
+		}
+	}
+	return sc.tpset.hasTypeDirective(named.Obj(), sc.opts.SealedDirective)
+}
+
+// implHandled checks if one of the case types matches the implementer. Only a
+// case for T matches an implementer T: an interface holding a T value does
+// not match a case for *T, even though *T also implements the interface. A
+// case for another interface matches every implementer of it.
+func implHandled(impl Implementer, handled []types.Type) bool {
+	for _, ht := range handled {
+		if types.Identical(ht, impl.Type) {
+			return true
+		}
+		if hi, ok := ht.Underlying().(*types.Interface); ok && types.Implements(impl.Type, hi) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsConstant(values []constant.Value, v constant.Value) bool {
+	for _, hv := range values {
+		if constant.Compare(hv, token.EQL, v) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,33 @@
+package structer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCheckSwitches(t *testing.T) {
+	pkg := "github.com/shabbyrobe/structer/testpkg/exhaustive"
+	tpset := NewTypePackageSet()
+	if _, err := tpset.Import(pkg); err != nil {
+		t.Fatal(err)
+	}
+	issues, err := tpset.CheckSwitches(SwitchOptions{Packages: []string{pkg}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out []string
+	for _, issue := range issues {
+		out = append(out, issue.String()[len(issue.Position.Filename)+1:])
+	}
+	expected := []string{
+		"26:2: switch over " + pkg + ".Color is missing cases: Green, Blue, hidden",
+		"47:2: switch over github.com/shabbyrobe/structer/testpkg/consts.TestEnum is missing cases: TestEnum2",
+		"69:2: type switch over " + pkg + ".Shape is missing cases: Square, Triangle",
+		"99:2: type switch over " + pkg + ".Sealed is missing cases: Circle",
+		"105:2: type switch over " + pkg + ".Shape is missing cases: Circle",
+	}
+	if !reflect.DeepEqual(expected, out) {
+		t.Fatalf("%q", out)
+	}
+}
@@ -0,0 +1,200 @@
+package structer
+
+import (
+	"fmt"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// minDetectedFlags is the number of distinct single bit constants a type
+// needs before EnumPolicy.PowerOfTwoFlags treats it as a FlagSet. Types with
+// fewer, like one with the values 1 and 2, are more likely to be ordinary
+// enums.
+const minDetectedFlags = 3
+
+// FlagSet describes an integer type whose constants are bits that can be
+// combined, typically declared using "1 << iota".
+type FlagSet struct {
+	Type TypeName
+
+	// Zero is the constant with the value 0, if there is one.
+	Zero *ConstValue
+
+	// Flags contains the constants with a single bit set, in declaration
+	// order. If several constants have the same value, only the first is
+	// included.
+	Flags []*ConstValue
+
+	// Masks contains the constants that combine several flags, like
+	// "ReadWrite = Read | Write".
+	Masks []*ConstValue
+}
+
+// NewFlagSet checks that all of the values are flags (or combinations of
+// flags) and returns them as a FlagSet.
+func NewFlagSet(consts *Consts) (*FlagSet, error) {
+	return newFlagSet(consts.Type, consts.Values)
+}
+
+// ExtractFlags extracts the constants of the type name using ExtractConsts
+// and returns them as a FlagSet. It fails if any of the constants is not a
+// flag or a combination of flags, but the type does not need to be detected
+// as a flag set by the EnumPolicy.
+func (t *TypePackageSet) ExtractFlags(name TypeName, includeUnexported bool) (*FlagSet, error) {
+	consts, err := t.ExtractConsts(name, includeUnexported)
+	if err != nil {
+		return nil, err
+	}
+	return NewFlagSet(consts)
+}
+
+func newFlagSet(name TypeName, values []*ConstValue) (*FlagSet, error) {
+	fs := &FlagSet{Type: name}
+	var rest []*ConstValue
+	for _, v := range values {
+		if v.Value.Kind() != constant.Int || constant.Sign(v.Value) < 0 {
+			return nil, fmt.Errorf("%s is not a flag: %s", v.Name, v.Value)
+		}
+		switch {
+		case constant.Sign(v.Value) == 0:
+			if fs.Zero == nil {
+				fs.Zero = v
+			}
+		case isSingleBit(v.Value):
+			if fs.find(v.Value) == nil {
+				fs.Flags = append(fs.Flags, v)
+			}
+		default:
+			rest = append(rest, v)
+		}
+	}
+
+	all := fs.All()
+	for _, v := range rest {
+		if constant.Sign(constant.BinaryOp(v.Value, token.AND_NOT, all)) != 0 {
+			return nil, fmt.Errorf("%s is not a combination of flags: %s", v.Name, v.Value)
+		}
+		fs.Masks = append(fs.Masks, v)
+	}
+	return fs, nil
+}
+
+// All returns the value with every flag set.
+func (f *FlagSet) All() constant.Value {
+	all := constant.MakeInt64(0)
+	for _, flag := range f.Flags {
+		all = constant.BinaryOp(all, token.OR, flag.Value)
+	}
+	return all
+}
+
+// Decompose splits v into the flags that are set in it, in declaration order.
+// Any bits that do not correspond to a flag are returned in rest.
+func (f *FlagSet) Decompose(v constant.Value) (flags []*ConstValue, rest constant.Value) {
+	rest = constant.ToInt(v)
+	for _, flag := range f.Flags {
+		if constant.Compare(constant.BinaryOp(rest, token.AND, flag.Value), token.EQL, flag.Value) {
+			flags = append(flags, flag)
+			rest = constant.BinaryOp(rest, token.AND_NOT, flag.Value)
+		}
+	}
+	return flags, rest
+}
+
+// Format renders v as the names of its flags separated by "|", i.e.
+// "Read|Write". Bits that do not correspond to a flag are rendered in
+// hexadecimal. Zero is rendered as the name of the Zero constant, or "0".
+func (f *FlagSet) Format(v constant.Value) string {
+	flags, rest := f.Decompose(v)
+	if len(flags) == 0 && constant.Sign(rest) == 0 {
+		if f.Zero != nil {
+			return f.Zero.Name.Name
+		}
+		return "0"
+	}
+	parts := make([]string, 0, len(flags)+1)
+	for _, flag := range flags {
+		parts = append(parts, flag.Name.Name)
+	}
+	if constant.Sign(rest) != 0 {
+		parts = append(parts, "0x"+constantHex(rest))
+	}
+	return strings.Join(parts, "|")
+}
+
+func (f *FlagSet) find(v constant.Value) *ConstValue {
+	for _, flag := range f.Flags {
+		if constant.Compare(flag.Value, token.EQL, v) {
+			return flag
+		}
+	}
+	return nil
+}
+
+// isFlags checks if the type name is a set of flags according to the
+// EnumPolicy. Types that are enums are only flags if they are marked with the
+// FlagsDirective.
+func (t *TypePackageSet) isFlags(name TypeName, def types.Object) bool {
+	if basic, ok := def.Type().Underlying().(*types.Basic); !ok || basic.Info()&types.IsInteger == 0 {
+		return false
+	}
+	policy := t.EnumPolicy
+	if policy.FlagsDirective != "" && t.hasTypeDirective(def, policy.FlagsDirective) {
+		return true
+	}
+
+	// An explicit enum whose values happen to be powers of two is still an
+	// enum. Only the FlagsDirective overrides it.
+	if named, ok := def.Type().(*types.Named); ok && t.isEnum(name, def, named) {
+		return false
+	}
+	if policy.PowerOfTwoFlags {
+		values := t.packageConsts(name.PackagePath, name, true)
+		fs, err := newFlagSet(name, values)
+		if err != nil || len(fs.Flags) < minDetectedFlags {
+			return false
+		}
+
+		// A plain iota enum like 0, 1, 2, 3, 4 also consists of flags and
+		// combinations of flags, so masks are only accepted if they are
+		// declared after all of the flags.
+		seenMask := false
+		for _, v := range values {
+			if fs.isMask(v) {
+				seenMask = true
+			} else if seenMask && isSingleBit(v.Value) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func (f *FlagSet) isMask(v *ConstValue) bool {
+	for _, mask := range f.Masks {
+		if mask == v {
+			return true
+		}
+	}
+	return false
+}
+
+func isSingleBit(v constant.Value) bool {
+	minus1 := constant.BinaryOp(v, token.SUB, constant.MakeInt64(1))
+	return constant.Sign(constant.BinaryOp(v, token.AND, minus1)) == 0
+}
+
+func constantHex(v constant.Value) string {
+	switch x := constant.Val(v).(type) {
+	case int64:
+		return strconv.FormatInt(x, 16)
+	case *big.Int:
+		return x.Text(16)
+	}
+	return v.ExactString()
+}
@@ -0,0 +1,151 @@
+package structer
+
+import (
+	"go/constant"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFlagsDetection(t *testing.T) {
+	pkg := "github.com/shabbyrobe/structer/testpkg/flags"
+	tpset := NewTypePackageSet()
+	if _, err := tpset.Import(pkg); err != nil {
+		t.Fatal(err)
+	}
+	for typ, expected := range map[string]bool{
+		"Perm":     true,
+		"Mode":     true,
+		"Level":    false,
+		"NotFlags": false,
+		"Shape":    false,
+		"Option":   true,
+	} {
+		consts, err := tpset.ExtractConsts(NewTypeName(pkg, typ), false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if consts.IsFlags != expected {
+			t.Errorf("expected %s IsFlags to be %v", typ, expected)
+		}
+	}
+}
+
+func TestFlagsEnumTypes(t *testing.T) {
+	pkg := "github.com/shabbyrobe/structer/testpkg/flags"
+	tpset := NewTypePackageSet()
+	if _, err := tpset.Import(pkg); err != nil {
+		t.Fatal(err)
+	}
+	names := tpset.EnumTypes(pkg)
+	expected := TypeNames{
+		NewTypeName(pkg, "Mode"),
+		NewTypeName(pkg, "Option"),
+		NewTypeName(pkg, "Perm"),
+		NewTypeName(pkg, "Shape"),
+	}
+	if !reflect.DeepEqual(expected, names) {
+		t.Fatal(names)
+	}
+	src, err := tpset.GenerateEnums(EnumGenOptions{TrimPrefix: true}, names...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGenerated(t, tpset, pkg, src)
+
+	// The explicit enum marker wins over the power of two heuristic.
+	src, err = tpset.GenerateEnums(EnumGenOptions{TrimPrefix: true}, NewTypeName(pkg, "Shape"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(src), `"|"`) {
+		t.Fatalf("%s", src)
+	}
+}
+
+func TestFlagSet(t *testing.T) {
+	pkg := "github.com/shabbyrobe/structer/testpkg/flags"
+	tpset := NewTypePackageSet()
+	if _, err := tpset.Import(pkg); err != nil {
+		t.Fatal(err)
+	}
+	fs, err := tpset.ExtractFlags(NewTypeName(pkg, "Perm"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var flags, masks []string
+	for _, f := range fs.Flags {
+		flags = append(flags, f.Name.Name)
+	}
+	for _, m := range fs.Masks {
+		masks = append(masks, m.Name.Name)
+	}
+	if fs.Zero == nil || fs.Zero.Name.Name != "PermNone" {
+		t.Fatal(fs.Zero)
+	}
+	if !reflect.DeepEqual([]string{"PermRead", "PermWrite", "PermExec"}, flags) {
+		t.Fatal(flags)
+	}
+	if !reflect.DeepEqual([]string{"PermReadWrite"}, masks) {
+		t.Fatal(masks)
+	}
+	if all, _ := constant.Int64Val(fs.All()); all != 14 {
+		t.Fatal(all)
+	}
+
+	decomposed, rest := fs.Decompose(constant.MakeInt64(2 | 8 | 32))
+	if len(decomposed) != 2 || decomposed[0].Name.Name != "PermRead" || decomposed[1].Name.Name != "PermExec" {
+		t.Fatal(decomposed)
+	}
+	if r, _ := constant.Int64Val(rest); r != 32 {
+		t.Fatal(r)
+	}
+
+	for v, expected := range map[int64]string{
+		0:  "PermNone",
+		2:  "PermRead",
+		6:  "PermRead|PermWrite",
+		17: "0x11",
+		10: "PermRead|PermExec",
+		42: "PermRead|PermExec|0x20",
+	} {
+		if s := fs.Format(constant.MakeInt64(v)); s != expected {
+			t.Errorf("%d: expected %q, found %q", v, expected, s)
+		}
+	}
+
+	if _, err := tpset.ExtractFlags(NewTypeName(pkg, "NotFlags"), false); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestGenerateEnumsFlags(t *testing.T) {
+	pkg := "github.com/shabbyrobe/structer/testpkg/flags"
+	tpset := NewTypePackageSet()
+	if _, err := tpset.Import(pkg); err != nil {
+		t.Fatal(err)
+	}
+	src, err := tpset.GenerateEnums(EnumGenOptions{TrimPrefix: true}, NewTypeName(pkg, "Perm"), NewTypeName(pkg, "Mode"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGenerated(t, tpset, pkg, src)
+
+	out := string(src)
+	for _, frag := range []string{
+		"\t\"strings\"\n",
+		"if v == 0 {\n\t\treturn \"None\"\n\t}",
+		"if v&PermWrite != 0 {\n\t\tnames = append(names, \"Write\")\n\t}",
+		"if rest := v &^ (PermRead | PermWrite | PermExec); rest != 0 {",
+		"return v&^(PermRead|PermWrite|PermExec) == 0",
+		"case \"ReadWrite\":\n\t\t\tv |= PermReadWrite",
+		"return []Perm{PermRead, PermWrite, PermExec}",
+		"if v == 0 {\n\t\treturn \"0\"\n\t}",
+		"case \"0\":\n\t\tcase \"A\":",
+	} {
+		if !strings.Contains(out, frag) {
+			t.Errorf("missing %q in:\n%s", frag, out)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package structer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestImplementersOf(t *testing.T) {
+	pkg := "github.com/shabbyrobe/structer/testpkg/impl"
+	tpset := NewTypePackageSet()
+	if _, err := tpset.Import(pkg); err != nil {
+		t.Fatal(err)
+	}
+	impls := tpset.ImplementersOf(tpset.MustFindObject(NewTypeName(pkg, "Shape")).Type())
+	if len(impls) != 2 {
+		t.Fatal(impls)
+	}
+	if impls[0].Name.Name != "Circle" || impls[0].Pointer || impls[0].Type.String() != pkg+".Circle" {
+		t.Fatal(impls[0])
+	}
+	if impls[1].Name.Name != "Square" || !impls[1].Pointer || impls[1].Type.String() != "*"+pkg+".Square" {
+		t.Fatal(impls[1])
+	}
+}
+
+func TestImplementersOfMatchesFindImplementers(t *testing.T) {
+	pkg := "github.com/shabbyrobe/structer/testpkg/impl"
+	tpset := NewTypePackageSet()
+	if _, err := tpset.Import(pkg); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Shape", "Anything"} {
+		tn := NewTypeName(pkg, name)
+		found, err := tpset.FindImplementers(tn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := TypeMap{}
+		for _, impl := range tpset.ImplementersOf(tpset.MustFindObject(tn).Type()) {
+			expected[impl.Name] = impl.Type
+		}
+		if len(expected) == 0 || !reflect.DeepEqual(expected, found) {
+			t.Fatal(name, expected, found)
+		}
+	}
+}
+
+func TestWalkImplementers(t *testing.T) {
+	pkg := "github.com/shabbyrobe/structer/testpkg/impl"
+	tpset := NewTypePackageSet()
+	if _, err := tpset.Import(pkg); err != nil {
+		t.Fatal(err)
+	}
+	tn := NewTypeName(pkg, "Drawing")
+	typ := tpset.MustFindObject(tn).Type().Underlying()
+
+	cv := &controlVisitor{}
+	if err := Walk(tn, typ, cv, WalkTypePackageSet(tpset), WalkImplementers(false)); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"EnterStruct v",
+		"EnterField v.Shape",
+		"VisitNamed v.Shape",
+		"EnterImplementer v.Shape.(impl.Circle)",
+		"LeaveImplementer v.Shape.(impl.Circle)",
+		"EnterImplementer v.Shape.(*impl.Square)",
+		"LeaveImplementer v.Shape.(*impl.Square)",
+		"LeaveField v.Shape",
+		"EnterField v.Inline",
+		"EnterInterface v.Inline",
+		"VisitMethod v.Inline",
+		"EnterImplementer v.Inline.(impl.Circle)",
+		"LeaveImplementer v.Inline.(impl.Circle)",
+		"EnterImplementer v.Inline.(*impl.Square)",
+		"LeaveImplementer v.Inline.(*impl.Square)",
+		"LeaveInterface v.Inline",
+		"LeaveField v.Inline",
+		"EnterField v.Any",
+		"EnterInterface v.Any",
+		"EnterImplementer v.Any.(impl.Circle)",
+		"LeaveImplementer v.Any.(impl.Circle)",
+		"EnterImplementer v.Any.(impl.Drawing)",
+		"LeaveImplementer v.Any.(impl.Drawing)",
+		"EnterImplementer v.Any.(impl.Square)",
+		"LeaveImplementer v.Any.(impl.Square)",
+		"LeaveInterface v.Any",
+		"LeaveField v.Any",
+		"LeaveStruct v",
+	}
+	if !reflect.DeepEqual(expected, cv.Events) {
+		t.Fatalf("%q", cv.Events)
+	}
+}
+
+func TestWalkImplementersDescend(t *testing.T) {
+	pkg := "github.com/shabbyrobe/structer/testpkg/impl"
+	tpset := NewTypePackageSet()
+	if _, err := tpset.Import(pkg); err != nil {
+		t.Fatal(err)
+	}
+	tn := NewTypeName(pkg, "Drawing")
+	typ := tpset.MustFindObject(tn).Type().Underlying()
+
+	var fields []string
+	vis := &PartialTypeVisitor{
+		EnterFieldFunc: func(ctx WalkContext, s StructInfo, field FieldInfo, tag StructTag) error {
+			fields = append(fields, ctx.Path().Expr("v"))
+			if field.Name() == "Inline" || field.Name() == "Any" {
+				return SkipChildren
+			}
+			return nil
+		},
+	}
+	if err := Walk(tn, typ, vis, WalkTypePackageSet(tpset), WalkImplementers(true)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Square contains a Shape, so Circle is visited again but Square is not
+	// descended into a second time.
+	expected := []string{
+		"v.Shape",
+		"v.Shape.(impl.Circle).Radius",
+		"v.Shape.(*impl.Square).Side",
+		"v.Shape.(*impl.Square).Inner",
+		"v.Shape.(*impl.Square).Inner.(impl.Circle).Radius",
+		"v.Inline",
+		"v.Any",
+	}
+	if !reflect.DeepEqual(expected, fields) {
+		t.Fatalf("%q", fields)
+	}
+}
+
+func TestWalkImplementersRequiresTypePackageSet(t *testing.T) {
+	pkg := "github.com/shabbyrobe/structer/testpkg/impl"
+	tpset := NewTypePackageSet()
+	if _, err := tpset.Import(pkg); err != nil {
+		t.Fatal(err)
+	}
+	tn := NewTypeName(pkg, "Drawing")
+	typ := tpset.MustFindObject(tn).Type().Underlying()
+	if err := Walk(tn, typ, &PartialTypeVisitor{}, WalkImplementers(false)); err == nil {
+		t.Fatal()
+	}
+}
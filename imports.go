@@ -0,0 +1,84 @@
+package structer
+
+import (
+	"bytes"
+	"fmt"
+	"go/types"
+	"path"
+	"sort"
+	"strconv"
+)
+
+// ImportNames assigns local names to the packages referred to by code
+// generated in the package PackagePath. Each package is given its own name
+// unless it collides with a package that has already been named, in which
+// case a number is appended, i.e. "errors2".
+type ImportNames struct {
+	PackagePath string
+
+	// imports maps package paths to local names, names maps local names back
+	// to package paths.
+	imports map[string]string
+	names   map[string]string
+}
+
+func NewImportNames(pkgPath string) *ImportNames {
+	return &ImportNames{
+		PackagePath: pkgPath,
+		imports:     make(map[string]string),
+		names:       make(map[string]string),
+	}
+}
+
+// Name returns the local name for the package pkgPath called pkgName, adding
+// it to the imports if necessary. It returns "" for PackagePath.
+func (n *ImportNames) Name(pkgPath, pkgName string) string {
+	if pkgPath == n.PackagePath {
+		return ""
+	}
+	if name, ok := n.imports[pkgPath]; ok {
+		return name
+	}
+	name := pkgName
+	for i := 2; n.names[name] != ""; i++ {
+		name = pkgName + strconv.Itoa(i)
+	}
+	n.imports[pkgPath], n.names[name] = name, pkgPath
+	return name
+}
+
+// Qualifier is a types.Qualifier that names packages using Name, for use with
+// types.TypeString.
+func (n *ImportNames) Qualifier(pkg *types.Package) string {
+	return n.Name(pkg.Path(), pkg.Name())
+}
+
+// Len returns the number of imported packages.
+func (n *ImportNames) Len() int {
+	return len(n.imports)
+}
+
+// Source renders an import declaration for every package that has been
+// named, sorted by path, or nothing if there are none.
+func (n *ImportNames) Source() []byte {
+	if len(n.imports) == 0 {
+		return nil
+	}
+	paths := make([]string, 0, len(n.imports))
+	for p := range n.imports {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	var buf bytes.Buffer
+	buf.WriteString("import (\n")
+	for _, p := range paths {
+		if local := n.imports[p]; local != path.Base(p) {
+			fmt.Fprintf(&buf, "%s %q\n", local, p)
+		} else {
+			fmt.Fprintf(&buf, "%q\n", p)
+		}
+	}
+	buf.WriteString(")\n")
+	return buf.Bytes()
+}
@@ -0,0 +1,324 @@
+//go:build go1.23
+// +build go1.23
+
+package structer
+
+import (
+	"fmt"
+	"go/types"
+	"iter"
+)
+
+// WalkEventKind identifies the TypeVisitor method that produced a WalkEvent.
+type WalkEventKind int
+
+const (
+	EventEnterStruct WalkEventKind = iota + 1
+	EventLeaveStruct
+	EventEnterField
+	EventLeaveField
+	EventEnterMap
+	EventLeaveMap
+	EventEnterMapKey
+	EventLeaveMapKey
+	EventEnterMapElem
+	EventLeaveMapElem
+	EventEnterPointer
+	EventLeavePointer
+	EventEnterSlice
+	EventLeaveSlice
+	EventEnterArray
+	EventLeaveArray
+	EventEnterInterface
+	EventLeaveInterface
+	EventVisitMethod
+	EventVisitEmbedded
+	EventEnterImplementer
+	EventLeaveImplementer
+	EventVisitBasic
+	EventVisitNamed
+	EventVisitInvalid
+)
+
+var walkEventKindNames = map[WalkEventKind]string{
+	EventEnterStruct:      "EnterStruct",
+	EventLeaveStruct:      "LeaveStruct",
+	EventEnterField:       "EnterField",
+	EventLeaveField:       "LeaveField",
+	EventEnterMap:         "EnterMap",
+	EventLeaveMap:         "LeaveMap",
+	EventEnterMapKey:      "EnterMapKey",
+	EventLeaveMapKey:      "LeaveMapKey",
+	EventEnterMapElem:     "EnterMapElem",
+	EventLeaveMapElem:     "LeaveMapElem",
+	EventEnterPointer:     "EnterPointer",
+	EventLeavePointer:     "LeavePointer",
+	EventEnterSlice:       "EnterSlice",
+	EventLeaveSlice:       "LeaveSlice",
+	EventEnterArray:       "EnterArray",
+	EventLeaveArray:       "LeaveArray",
+	EventEnterInterface:   "EnterInterface",
+	EventLeaveInterface:   "LeaveInterface",
+	EventVisitMethod:      "VisitMethod",
+	EventVisitEmbedded:    "VisitEmbedded",
+	EventEnterImplementer: "EnterImplementer",
+	EventLeaveImplementer: "LeaveImplementer",
+	EventVisitBasic:       "VisitBasic",
+	EventVisitNamed:       "VisitNamed",
+	EventVisitInvalid:     "VisitInvalid",
+}
+
+func (k WalkEventKind) String() string {
+	if name, ok := walkEventKindNames[k]; ok {
+		return name
+	}
+	return fmt.Sprintf("WalkEventKind(%d)", int(k))
+}
+
+// WalkEvent is a single call to a TypeVisitor method, as yielded by
+// WalkEvents. Only the fields relevant to Kind are populated.
+type WalkEvent struct {
+	Kind WalkEventKind
+
+	// Type is the type of the node: the struct for EnterStruct, the field's
+	// type for EnterField, the key type for EnterMapKey, the method's
+	// signature for VisitMethod, the implementing type for EnterImplementer
+	// and so on.
+	Type types.Type
+
+	// Path and Stack are copies of WalkContext.Path and WalkContext.Stack.
+	Path  WalkPath
+	Stack []types.Type
+
+	// Struct is populated for struct and field events, Field and Tag for
+	// field events.
+	Struct StructInfo
+	Field  FieldInfo
+	Tag    StructTag
+
+	// Map is populated for map, map key and map elem events.
+	Map *types.Map
+
+	// Interface is populated for interface, method and embedded events,
+	// Method for VisitMethod.
+	Interface *types.Interface
+	Method    MethodInfo
+
+	// Implementer and ImplementerOf are populated for implementer events.
+	// ImplementerOf is the *types.Named or *types.Interface implemented.
+	Implementer   Implementer
+	ImplementerOf types.Type
+
+	skip *bool
+}
+
+// SkipChildren prevents the walk from descending into the children of the
+// node, the same as returning SkipChildren from an Enter method of a
+// TypeVisitor. It has no effect on other events.
+func (e WalkEvent) SkipChildren() {
+	if e.skip != nil {
+		*e.skip = true
+	}
+}
+
+// WalkEvents returns an iterator over the events produced by Walk, allowing
+// simple generators to use a for-range loop rather than implement a
+// TypeVisitor:
+//
+//	for ev, err := range structer.WalkEvents(tn, typ) {
+//		if err != nil {
+//			return err
+//		}
+//		if ev.Kind == structer.EventEnterField {
+//			fmt.Println(ev.Path.Expr("v"), ev.Type)
+//		}
+//	}
+//
+// Breaking out of the loop stops the Walk. If the Walk fails, the error is
+// yielded with an empty WalkEvent as the final element.
+//
+func WalkEvents(tn TypeName, t types.Type, opts ...WalkOption) iter.Seq2[WalkEvent, error] {
+	return func(yield func(WalkEvent, error) bool) {
+		ev := &eventVisitor{yield: yield}
+		if err := Walk(tn, t, ev, opts...); err != nil && !ev.stopped {
+			yield(WalkEvent{}, err)
+		}
+	}
+}
+
+// AllObjects returns an iterator over every object in Objects, sorted by
+// name.
+func (t *TypePackageSet) AllObjects() iter.Seq2[TypeName, types.Object] {
+	return func(yield func(TypeName, types.Object) bool) {
+		names := make(TypeNames, 0, len(t.Objects))
+		for name := range t.Objects {
+			names = append(names, name)
+		}
+		names.Sort()
+		for _, name := range names {
+			if !yield(name, t.Objects[name]) {
+				return
+			}
+		}
+	}
+}
+
+// Implementers returns an iterator over the result of FindImplementers,
+// sorted by name.
+func (t *TypePackageSet) Implementers(ifaceName TypeName) (iter.Seq2[TypeName, types.Type], error) {
+	impls, err := t.FindImplementers(ifaceName)
+	if err != nil {
+		return nil, err
+	}
+	return impls.All(), nil
+}
+
+// All returns an iterator over the TypeMap, sorted by name.
+func (m TypeMap) All() iter.Seq2[TypeName, types.Type] {
+	return func(yield func(TypeName, types.Type) bool) {
+		for _, name := range m.SortedKeys() {
+			if !yield(name, m[name]) {
+				return
+			}
+		}
+	}
+}
+
+// All returns an iterator over the values in the order they were declared.
+// Use SortedValues to iterate in order of name.
+func (e *Consts) All() iter.Seq[*ConstValue] {
+	return func(yield func(*ConstValue) bool) {
+		for _, v := range e.Values {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// eventVisitor is a TypeVisitor that yields each call as a WalkEvent.
+type eventVisitor struct {
+	yield   func(WalkEvent, error) bool
+	stopped bool
+}
+
+// interface check
+var _ TypeVisitor = &eventVisitor{}
+
+func (v *eventVisitor) emit(ctx WalkContext, ev WalkEvent) error {
+	var skip bool
+	ev.skip = &skip
+	ev.Path = ctx.Path()
+	stack := ctx.Stack()
+	ev.Stack = make([]types.Type, len(stack))
+	copy(ev.Stack, stack)
+
+	if !v.yield(ev, nil) {
+		v.stopped = true
+		return StopWalk
+	}
+	if skip {
+		return SkipChildren
+	}
+	return nil
+}
+
+func (v *eventVisitor) EnterStruct(ctx WalkContext, s StructInfo) error {
+	return v.emit(ctx, WalkEvent{Kind: EventEnterStruct, Type: s.Struct, Struct: s})
+}
+
+func (v *eventVisitor) LeaveStruct(ctx WalkContext, s StructInfo) error {
+	return v.emit(ctx, WalkEvent{Kind: EventLeaveStruct, Type: s.Struct, Struct: s})
+}
+
+func (v *eventVisitor) EnterField(ctx WalkContext, s StructInfo, field FieldInfo, tag StructTag) error {
+	return v.emit(ctx, WalkEvent{Kind: EventEnterField, Type: field.Type(), Struct: s, Field: field, Tag: tag})
+}
+
+func (v *eventVisitor) LeaveField(ctx WalkContext, s StructInfo, field FieldInfo, tag StructTag) error {
+	return v.emit(ctx, WalkEvent{Kind: EventLeaveField, Type: field.Type(), Struct: s, Field: field, Tag: tag})
+}
+
+func (v *eventVisitor) EnterMap(ctx WalkContext, ft *types.Map) error {
+	return v.emit(ctx, WalkEvent{Kind: EventEnterMap, Type: ft, Map: ft})
+}
+
+func (v *eventVisitor) LeaveMap(ctx WalkContext, ft *types.Map) error {
+	return v.emit(ctx, WalkEvent{Kind: EventLeaveMap, Type: ft, Map: ft})
+}
+
+func (v *eventVisitor) EnterMapKey(ctx WalkContext, ft *types.Map, key types.Type) error {
+	return v.emit(ctx, WalkEvent{Kind: EventEnterMapKey, Type: key, Map: ft})
+}
+
+func (v *eventVisitor) LeaveMapKey(ctx WalkContext, ft *types.Map, key types.Type) error {
+	return v.emit(ctx, WalkEvent{Kind: EventLeaveMapKey, Type: key, Map: ft})
+}
+
+func (v *eventVisitor) EnterMapElem(ctx WalkContext, ft *types.Map, elem types.Type) error {
+	return v.emit(ctx, WalkEvent{Kind: EventEnterMapElem, Type: elem, Map: ft})
+}
+
+func (v *eventVisitor) LeaveMapElem(ctx WalkContext, ft *types.Map, elem types.Type) error {
+	return v.emit(ctx, WalkEvent{Kind: EventLeaveMapElem, Type: elem, Map: ft})
+}
+
+func (v *eventVisitor) EnterPointer(ctx WalkContext, ft *types.Pointer) error {
+	return v.emit(ctx, WalkEvent{Kind: EventEnterPointer, Type: ft})
+}
+
+func (v *eventVisitor) LeavePointer(ctx WalkContext, ft *types.Pointer) error {
+	return v.emit(ctx, WalkEvent{Kind: EventLeavePointer, Type: ft})
+}
+
+func (v *eventVisitor) EnterSlice(ctx WalkContext, ft *types.Slice) error {
+	return v.emit(ctx, WalkEvent{Kind: EventEnterSlice, Type: ft})
+}
+
+func (v *eventVisitor) LeaveSlice(ctx WalkContext, ft *types.Slice) error {
+	return v.emit(ctx, WalkEvent{Kind: EventLeaveSlice, Type: ft})
+}
+
+func (v *eventVisitor) EnterArray(ctx WalkContext, ft *types.Array) error {
+	return v.emit(ctx, WalkEvent{Kind: EventEnterArray, Type: ft})
+}
+
+func (v *eventVisitor) LeaveArray(ctx WalkContext, ft *types.Array) error {
+	return v.emit(ctx, WalkEvent{Kind: EventLeaveArray, Type: ft})
+}
+
+func (v *eventVisitor) EnterInterface(ctx WalkContext, t *types.Interface) error {
+	return v.emit(ctx, WalkEvent{Kind: EventEnterInterface, Type: t, Interface: t})
+}
+
+func (v *eventVisitor) LeaveInterface(ctx WalkContext, t *types.Interface) error {
+	return v.emit(ctx, WalkEvent{Kind: EventLeaveInterface, Type: t, Interface: t})
+}
+
+func (v *eventVisitor) VisitMethod(ctx WalkContext, iface *types.Interface, method MethodInfo) error {
+	return v.emit(ctx, WalkEvent{Kind: EventVisitMethod, Type: method.Signature, Interface: iface, Method: method})
+}
+
+func (v *eventVisitor) VisitEmbedded(ctx WalkContext, iface *types.Interface, embedded types.Type) error {
+	return v.emit(ctx, WalkEvent{Kind: EventVisitEmbedded, Type: embedded, Interface: iface})
+}
+
+func (v *eventVisitor) VisitBasic(ctx WalkContext, t *types.Basic) error {
+	return v.emit(ctx, WalkEvent{Kind: EventVisitBasic, Type: t})
+}
+
+func (v *eventVisitor) VisitNamed(ctx WalkContext, t *types.Named) error {
+	return v.emit(ctx, WalkEvent{Kind: EventVisitNamed, Type: t})
+}
+
+func (v *eventVisitor) VisitInvalid(ctx WalkContext, root TypeName, t *types.Basic) error {
+	return v.emit(ctx, WalkEvent{Kind: EventVisitInvalid, Type: t})
+}
+
+func (v *eventVisitor) EnterImplementer(ctx WalkContext, iface types.Type, impl Implementer) error {
+	return v.emit(ctx, WalkEvent{Kind: EventEnterImplementer, Type: impl.Type, ImplementerOf: iface, Implementer: impl})
+}
+
+func (v *eventVisitor) LeaveImplementer(ctx WalkContext, iface types.Type, impl Implementer) error {
+	return v.emit(ctx, WalkEvent{Kind: EventLeaveImplementer, Type: impl.Type, ImplementerOf: iface, Implementer: impl})
+}
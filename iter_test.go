@@ -0,0 +1,129 @@
+//go:build go1.23
+// +build go1.23
+
+package structer
+
+import (
+	"go/types"
+	"reflect"
+	"testing"
+)
+
+func TestWalkEvents(t *testing.T) {
+	_, tn, tst := getTestingStruct(t, "github.com/shabbyrobe/structer.TestingControlStruct")
+	cv := &controlVisitor{}
+	if err := Walk(tn, tst, cv); err != nil {
+		t.Fatal(err)
+	}
+
+	var events []string
+	for ev, err := range WalkEvents(tn, tst) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, ev.Kind.String()+" "+ev.Path.Expr("v"))
+	}
+	if !reflect.DeepEqual(cv.Events, events) {
+		t.Fatalf("%v\n!=\n%v", cv.Events, events)
+	}
+}
+
+func TestWalkEventsBreak(t *testing.T) {
+	_, tn, tst := getTestingStruct(t, "github.com/shabbyrobe/structer.TestingControlStruct")
+	n := 0
+	for ev := range WalkEvents(tn, tst) {
+		n++
+		if ev.Kind == EventVisitBasic {
+			break
+		}
+	}
+	if n != 5 {
+		t.Fatal(n)
+	}
+}
+
+func TestWalkEventsSkipChildren(t *testing.T) {
+	_, tn, tst := getTestingStruct(t, "github.com/shabbyrobe/structer.TestingControlStruct")
+	var fields []string
+	for ev, err := range WalkEvents(tn, tst) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ev.Kind == EventEnterField {
+			fields = append(fields, ev.Field.Name())
+			if _, ok := ev.Type.(*types.Struct); ok {
+				ev.SkipChildren()
+			}
+		}
+	}
+	expected := []string{"Struct", "Map", "Pointer", "Slice", "Array", "Interface"}
+	if !reflect.DeepEqual(expected, fields) {
+		t.Fatal(fields)
+	}
+}
+
+func TestTypePackageSetIterators(t *testing.T) {
+	pkg := "github.com/shabbyrobe/structer/testpkg/consts"
+	tpset := NewTypePackageSet()
+	if _, err := tpset.Import(pkg); err != nil {
+		t.Fatal(err)
+	}
+
+	var last TypeName
+	n := 0
+	for name, obj := range tpset.AllObjects() {
+		if n > 0 && !last.IsBefore(name) {
+			t.Fatal(last, name)
+		}
+		if obj != tpset.Objects[name] {
+			t.Fatal(name)
+		}
+		last = name
+		n++
+	}
+	if n != len(tpset.Objects) {
+		t.Fatal(n)
+	}
+
+	consts, err := tpset.ExtractConsts(NewTypeName(pkg, "TestEnum"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var values []string
+	for cv := range consts.All() {
+		values = append(values, cv.Name.Name)
+	}
+	if !reflect.DeepEqual([]string{"TestEnum1", "TestEnum2"}, values) {
+		t.Fatal(values)
+	}
+
+	// Values are yielded in declaration order, not by name.
+	mpkg := "github.com/shabbyrobe/structer/testpkg/constsmeta"
+	if _, err := tpset.Import(mpkg); err != nil {
+		t.Fatal(err)
+	}
+	consts, err = tpset.ExtractConsts(NewTypeName(mpkg, "Level"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	values = nil
+	for cv := range consts.All() {
+		values = append(values, cv.Name.Name)
+	}
+	if !reflect.DeepEqual([]string{"Warn", "Debug", "Info", "Error", "Fatal", "Trace", "Verbose"}, values) {
+		t.Fatal(values)
+	}
+
+	ipkg := "github.com/shabbyrobe/structer/testpkg/intfdecl1"
+	impls, err := tpset.Implementers(NewTypeName(ipkg, "Test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for name := range impls {
+		names = append(names, name.Name)
+	}
+	if !reflect.DeepEqual([]string{"TestPrimitive", "TestStruct", "TestStructPtr"}, names) {
+		t.Fatal(names)
+	}
+}
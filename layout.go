@@ -0,0 +1,104 @@
+package structer
+
+import (
+	"bytes"
+	"fmt"
+	"go/types"
+	"text/tabwriter"
+)
+
+// SizesFor returns the types.Sizes used by the gc compiler for goarch.
+func SizesFor(goarch string) (types.Sizes, error) {
+	sizes := types.SizesFor("gc", goarch)
+	if sizes == nil {
+		return nil, fmt.Errorf("unknown GOARCH %q", goarch)
+	}
+	return sizes, nil
+}
+
+// FieldLayout describes the position of a single field within the memory
+// occupied by a struct.
+type FieldLayout struct {
+	Field *types.Var
+	Index int
+
+	Offset int64
+	Size   int64
+	Align  int64
+
+	// Padding is the number of bytes inserted between the end of the
+	// previous field and the start of this one to satisfy Align.
+	Padding int64
+}
+
+// StructLayout describes the memory layout of a struct for a specific
+// types.Sizes.
+type StructLayout struct {
+	Struct *types.Struct
+	Fields []FieldLayout
+
+	Size  int64
+	Align int64
+
+	// TrailingPadding is the number of bytes between the end of the last
+	// field and the end of the struct.
+	TrailingPadding int64
+}
+
+// NewStructLayout calculates the layout of s using sizes.
+func NewStructLayout(s *types.Struct, sizes types.Sizes) *StructLayout {
+	n := s.NumFields()
+	vars := make([]*types.Var, n)
+	for i := 0; i < n; i++ {
+		vars[i] = s.Field(i)
+	}
+
+	layout := &StructLayout{
+		Struct: s,
+		Fields: make([]FieldLayout, n),
+		Size:   sizes.Sizeof(s),
+		Align:  sizes.Alignof(s),
+	}
+
+	var end int64
+	offsets := sizes.Offsetsof(vars)
+	for i, v := range vars {
+		fl := FieldLayout{
+			Field:  v,
+			Index:  i,
+			Offset: offsets[i],
+			Size:   sizes.Sizeof(v.Type()),
+			Align:  sizes.Alignof(v.Type()),
+		}
+		fl.Padding = fl.Offset - end
+		end = fl.Offset + fl.Size
+		layout.Fields[i] = fl
+	}
+	layout.TrailingPadding = layout.Size - end
+
+	return layout
+}
+
+// Padding returns the total number of bytes wasted by alignment padding,
+// including trailing padding.
+func (l *StructLayout) Padding() int64 {
+	pad := l.TrailingPadding
+	for _, f := range l.Fields {
+		pad += f.Padding
+	}
+	return pad
+}
+
+// String renders the layout as a table suitable for display to a user.
+func (l *StructLayout) String() string {
+	var buf bytes.Buffer
+	tw := tabwriter.NewWriter(&buf, 0, 4, 1, ' ', 0)
+	fmt.Fprintf(tw, "offset\tsize\talign\tpadding\tfield\ttype\n")
+	for _, f := range l.Fields {
+		fmt.Fprintf(tw, "%d\t%d\t%d\t%d\t%s\t%s\n", f.Offset, f.Size, f.Align, f.Padding, f.Field.Name(), f.Field.Type())
+	}
+	fmt.Fprintf(tw, "%d\t\t\t%d\t\t\n", l.Size, l.TrailingPadding)
+	tw.Flush()
+	fmt.Fprintf(&buf, "size %d, align %d, padding %d\n", l.Size, l.Align, l.Padding())
+	return buf.String()
+}
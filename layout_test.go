@@ -0,0 +1,127 @@
+package structer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLayout(t *testing.T) {
+	pkg := "github.com/shabbyrobe/structer/testpkg/layout"
+
+	for _, tc := range []struct {
+		arch    string
+		typ     string
+		offsets []int64
+		size    int64
+		padding int64
+	}{
+		{"amd64", "Padded", []int64{0, 8, 16, 20}, 24, 10},
+		{"386", "Padded", []int64{0, 4, 12, 16}, 20, 6},
+		{"amd64", "Packed", []int64{0, 8, 12, 13}, 16, 2},
+	} {
+		tpset := NewTypePackageSet()
+		tpset.Config.GOARCH = tc.arch
+		if _, err := tpset.Import(pkg); err != nil {
+			t.Fatal(err)
+		}
+		layout, err := tpset.Layout(NewTypeName(pkg, tc.typ))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if layout.Size != tc.size {
+			t.Fatalf("%s %s: size %d != %d", tc.arch, tc.typ, layout.Size, tc.size)
+		}
+		if layout.Padding() != tc.padding {
+			t.Fatalf("%s %s: padding %d != %d", tc.arch, tc.typ, layout.Padding(), tc.padding)
+		}
+		for i, f := range layout.Fields {
+			if f.Offset != tc.offsets[i] {
+				t.Fatalf("%s %s: field %s offset %d != %d", tc.arch, tc.typ, f.Field.Name(), f.Offset, tc.offsets[i])
+			}
+		}
+	}
+}
+
+func TestLayoutUnknownArch(t *testing.T) {
+	tpset := NewTypePackageSet()
+	tpset.Config.GOARCH = "pants"
+	pkg := "github.com/shabbyrobe/structer/testpkg/layout"
+	if _, err := tpset.Import(pkg); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tpset.Layout(NewTypeName(pkg, "Padded")); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestWalkLayout(t *testing.T) {
+	tpset := NewTypePackageSet()
+	pkg := "github.com/shabbyrobe/structer/testpkg/layout"
+	if _, err := tpset.Import(pkg); err != nil {
+		t.Fatal(err)
+	}
+	tn := NewTypeName(pkg, "Nested")
+	typ := tpset.MustFindObject(tn).Type().Underlying()
+
+	sizes, _ := SizesFor("amd64")
+	var structSizes []int64
+	offsets := map[string]int64{}
+	vis := &PartialTypeVisitor{
+		EnterStructFunc: func(ctx WalkContext, s StructInfo) error {
+			structSizes = append(structSizes, s.Layout.Size)
+			return nil
+		},
+		EnterFieldFunc: func(ctx WalkContext, s StructInfo, field FieldInfo, tag StructTag) error {
+			offsets[ctx.Path().Expr("v")] = field.Layout.Offset
+			return nil
+		},
+	}
+	if err := Walk(tn, typ, vis, WalkSizes(sizes)); err != nil {
+		t.Fatal(err)
+	}
+	if len(structSizes) != 2 || structSizes[0] != 24 || structSizes[1] != 16 {
+		t.Fatal(structSizes)
+	}
+	if offsets["v.Anon"] != 8 || offsets["v.Anon.Y"] != 8 {
+		t.Fatal(offsets)
+	}
+}
+
+func TestWalkLayoutTypePackageSet(t *testing.T) {
+	tpset := NewTypePackageSet()
+	tpset.Config.GOARCH = "386"
+	pkg := "github.com/shabbyrobe/structer/testpkg/layout"
+	if _, err := tpset.Import(pkg); err != nil {
+		t.Fatal(err)
+	}
+	tn := NewTypeName(pkg, "Padded")
+	typ := tpset.MustFindObject(tn).Type().Underlying()
+
+	// Without WalkSizes, the layout comes from the TypePackageSet's GOARCH
+	// rather than the host's.
+	var size int64
+	var offsets []int64
+	vis := &PartialTypeVisitor{
+		EnterStructFunc: func(ctx WalkContext, s StructInfo) error {
+			size = s.Layout.Size
+			return nil
+		},
+		EnterFieldFunc: func(ctx WalkContext, s StructInfo, field FieldInfo, tag StructTag) error {
+			offsets = append(offsets, field.Layout.Offset)
+			return nil
+		},
+	}
+	if err := Walk(tn, typ, vis, WalkTypePackageSet(tpset)); err != nil {
+		t.Fatal(err)
+	}
+	layout, err := tpset.Layout(tn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != 20 || size != layout.Size {
+		t.Fatal(size, layout.Size)
+	}
+	if !reflect.DeepEqual([]int64{0, 4, 12, 16}, offsets) {
+		t.Fatal(offsets)
+	}
+}
@@ -0,0 +1,169 @@
+package structer
+
+import (
+	"fmt"
+	"go/types"
+	"strings"
+)
+
+// PathKind identifies a single step taken by Walk when descending from the
+// root type to a child node.
+type PathKind int
+
+const (
+	PathField PathKind = iota + 1
+	PathMapKey
+	PathMapElem
+	PathSliceElem
+	PathArrayElem
+	PathDeref
+
+	// PathTypeAssert asserts that an interface value holds the implementer
+	// in PathElem.Type. See WalkImplementers.
+	PathTypeAssert
+)
+
+func (k PathKind) String() string {
+	switch k {
+	case PathField:
+		return "field"
+	case PathMapKey:
+		return "mapkey"
+	case PathMapElem:
+		return "mapelem"
+	case PathSliceElem:
+		return "sliceelem"
+	case PathArrayElem:
+		return "arrayelem"
+	case PathDeref:
+		return "deref"
+	case PathTypeAssert:
+		return "typeassert"
+	default:
+		return fmt.Sprintf("PathKind(%d)", int(k))
+	}
+}
+
+// PathElem is one step in a WalkPath.
+type PathElem struct {
+	Kind PathKind
+
+	// Type is the type being stepped into: the struct for PathField, the
+	// map for PathMapKey and PathMapElem, and so on. For PathTypeAssert, it
+	// is the asserted type.
+	Type types.Type
+
+	// Field and Index are only set if Kind is PathField. Index is the
+	// position of the field in the containing struct.
+	Field *types.Var
+	Index int
+}
+
+// IsLoop reports whether a generator would need a loop to reach the child
+// of this step.
+func (e PathElem) IsLoop() bool {
+	switch e.Kind {
+	case PathMapKey, PathMapElem, PathSliceElem, PathArrayElem:
+		return true
+	}
+	return false
+}
+
+// WalkPath describes how to reach the current node from the root of a Walk,
+// expressed as a list of field selections, map keys and elems, slice and array
+// indexes and pointer dereferences.
+type WalkPath []PathElem
+
+// Last returns the final step in the path, or false if the path is empty.
+func (p WalkPath) Last() (elem PathElem, ok bool) {
+	if len(p) == 0 {
+		return elem, false
+	}
+	return p[len(p)-1], true
+}
+
+// LoopVar returns the generated loop variable name used by Expr for the step
+// at index idx, i.e. "k1" for the key of the first map or "i2" for the index of
+// the second slice or array. An empty string is returned if the step at idx
+// does not require a loop.
+//
+// Map keys and elems share the same loop variable, so the loop:
+//
+//	for k1 := range v.Foo { ... }
+//
+// can use the expression "k1" for the key and "v.Foo[k1]" for the elem.
+//
+func (p WalkPath) LoopVar(idx int) string {
+	if idx < 0 || idx >= len(p) || !p[idx].IsLoop() {
+		return ""
+	}
+	n := 0
+	for i := 0; i <= idx; i++ {
+		if p[i].IsLoop() {
+			n++
+		}
+	}
+	if p[idx].Kind == PathMapKey || p[idx].Kind == PathMapElem {
+		return fmt.Sprintf("k%d", n)
+	}
+	return fmt.Sprintf("i%d", n)
+}
+
+// Expr renders the path as a Go expression relative to the root variable, for
+// example "v.Foo.Bar[k1].Baz". Loop variable names are generated using the
+// same scheme as LoopVar.
+//
+// Pointers are dereferenced implicitly where Go allows it (i.e. when
+// selecting a field), otherwise an explicit dereference is rendered:
+// "(*v.Foo)[i1]" or "*v.Bar".
+//
+// Type assertions are qualified using package names rather than paths, i.e.
+// "v.Shape.(*shapes.Square)".
+//
+func (p WalkPath) Expr(root string) string {
+	expr := root
+	derefs := 0
+
+	applyDerefs := func(keep int) {
+		if derefs > keep {
+			expr = "(" + strings.Repeat("*", derefs-keep) + expr + ")"
+		}
+		derefs = 0
+	}
+
+	for i, e := range p {
+		switch e.Kind {
+		case PathDeref:
+			derefs++
+
+		case PathField:
+			// Field selectors dereference a single pointer automatically.
+			applyDerefs(1)
+			expr += "." + e.Field.Name()
+
+		case PathMapKey:
+			expr, derefs = p.LoopVar(i), 0
+
+		case PathMapElem, PathSliceElem, PathArrayElem:
+			applyDerefs(0)
+			expr += "[" + p.LoopVar(i) + "]"
+
+		case PathTypeAssert:
+			applyDerefs(0)
+			expr += ".(" + types.TypeString(e.Type, qualifyPackageName) + ")"
+		}
+	}
+
+	if derefs > 0 {
+		expr = strings.Repeat("*", derefs) + expr
+	}
+	return expr
+}
+
+func qualifyPackageName(pkg *types.Package) string {
+	return pkg.Name()
+}
+
+func (p WalkPath) String() string {
+	return p.Expr("")
+}
@@ -0,0 +1,72 @@
+package structer
+
+import (
+	"go/types"
+	"reflect"
+	"testing"
+)
+
+func TestWalkPathExpr(t *testing.T) {
+	exprs := []string{}
+	vis := &PartialTypeVisitor{
+		VisitBasicFunc: func(ctx WalkContext, t *types.Basic) error {
+			exprs = append(exprs, ctx.Path().Expr("v"))
+			return nil
+		},
+		VisitNamedFunc: func(ctx WalkContext, t *types.Named) error {
+			exprs = append(exprs, ctx.Path().Expr("v"))
+			return nil
+		},
+	}
+	_, tn, tst := getTestingStruct(t, "github.com/shabbyrobe/structer.TestingStackStruct")
+	if err := Walk(tn, tst, vis); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"k2[i3]",
+		"k4[i5]",
+		"v.Foo[i1][k2].Bar[i3][k4].Baz",
+		"*v.Foo[i1][k2].Bar[i3][k4].Qux",
+		"v.Bar",
+	}
+	if !reflect.DeepEqual(expected, exprs) {
+		t.Fatalf("%q != %q", exprs, expected)
+	}
+}
+
+func TestWalkPathExprDeref(t *testing.T) {
+	pkg := types.NewPackage("test", "test")
+	foo := types.NewField(0, pkg, "Foo", types.Typ[types.Int], false)
+	bar := types.NewField(0, pkg, "Bar", types.Typ[types.Int], false)
+
+	for _, tc := range []struct {
+		path WalkPath
+		out  string
+	}{
+		{WalkPath{}, "v"},
+		{WalkPath{{Kind: PathDeref}}, "*v"},
+		{WalkPath{{Kind: PathDeref}, {Kind: PathField, Field: foo}}, "v.Foo"},
+		{WalkPath{{Kind: PathDeref}, {Kind: PathDeref}, {Kind: PathField, Field: foo}}, "(*v).Foo"},
+		{WalkPath{{Kind: PathField, Field: foo}, {Kind: PathDeref}, {Kind: PathSliceElem}}, "(*v.Foo)[i1]"},
+		{WalkPath{{Kind: PathField, Field: foo}, {Kind: PathMapKey}, {Kind: PathField, Field: bar}}, "k1.Bar"},
+		{WalkPath{{Kind: PathField, Field: foo}, {Kind: PathMapElem}, {Kind: PathArrayElem}}, "v.Foo[k1][i2]"},
+	} {
+		if out := tc.path.Expr("v"); out != tc.out {
+			t.Fatalf("%q != %q", out, tc.out)
+		}
+	}
+}
+
+func TestWalkPathLoopVar(t *testing.T) {
+	path := WalkPath{{Kind: PathSliceElem}, {Kind: PathDeref}, {Kind: PathMapKey}}
+	if v := path.LoopVar(0); v != "i1" {
+		t.Fatal(v)
+	}
+	if v := path.LoopVar(1); v != "" {
+		t.Fatal(v)
+	}
+	if v := path.LoopVar(2); v != "k2" {
+		t.Fatal(v)
+	}
+}
@@ -0,0 +1,295 @@
+package structer
+
+import (
+	"fmt"
+	"go/types"
+	"sort"
+)
+
+// EffectiveField is a field that can be selected directly on a struct, either
+// because it is declared on the struct or because it has been promoted from
+// an embedded struct.
+type EffectiveField struct {
+	Field *types.Var
+	Tag   string
+
+	// Name of the field. If FieldSetOptions.TagKey or FieldSetOptions.Naming
+	// is set, this is the wire name.
+	Name string
+
+	// Tagged is true if the Name came from a tag.
+	Tagged bool
+
+	// Wire is only populated if FieldSetOptions.TagKey or
+	// FieldSetOptions.Naming is set.
+	Wire WireField
+
+	// Depth is 0 for fields declared on the struct, 1 for fields promoted
+	// from a struct embedded in the struct, and so on.
+	Depth int
+
+	// Embedding contains the embedded fields that must be traversed to reach
+	// Field, outermost first. It is empty if Depth is 0.
+	Embedding []*types.Var
+
+	// Index is the sequence of field indexes used to reach Field, like
+	// reflect.StructField.Index.
+	Index []int
+}
+
+// FieldConflict describes a name that is declared more than once at the
+// shallowest depth it appears at. Go does not promote ambiguous fields, so
+// they do not appear in FieldSet.Fields.
+type FieldConflict struct {
+	Name   string
+	Depth  int
+	Fields []EffectiveField
+}
+
+// FieldSet is the flattened set of fields that can be selected on a struct.
+type FieldSet struct {
+	Fields    []EffectiveField
+	Conflicts []FieldConflict
+}
+
+// FieldSetOptions controls how EffectiveFields resolves names.
+type FieldSetOptions struct {
+	// TagKey enables encoding/json style resolution using the tag key
+	// supplied, i.e. "json". Names are taken from the tag, fields tagged with
+	// "-" are ignored, unexported fields are ignored, embedded structs are only
+	// flattened if they don't have a name in the tag, and a tagged field wins
+	// over untagged fields at the same depth.
+	//
+	// If TagKey is empty, Go's promotion and shadowing rules are used.
+	TagKey string
+
+	// Naming resolves names using a NamingPolicy, which takes precedence
+	// over TagKey. Fields are flattened if NamingPolicy.Resolve reports them
+	// as Inline.
+	Naming *NamingPolicy
+}
+
+func (o FieldSetOptions) naming() *NamingPolicy {
+	if o.Naming != nil {
+		return o.Naming
+	}
+	if o.TagKey != "" {
+		return &NamingPolicy{TagKey: o.TagKey, InlineEmbedded: true}
+	}
+	return nil
+}
+
+// Lookup returns the effective field called name.
+func (fs *FieldSet) Lookup(name string) (field EffectiveField, ok bool) {
+	for _, f := range fs.Fields {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return field, false
+}
+
+// EffectiveFields calculates the effective, flattened field list for s,
+// taking embedding, promotion and shadowing into account.
+//
+// Embedded structs (and pointers to structs) are expanded in place; the
+// embedded field itself does not appear in FieldSet.Fields but its name still
+// shadows deeper fields. Fields are returned in the order they would be
+// encountered in a depth-first traversal of the struct's declaration.
+//
+func EffectiveFields(s *types.Struct, opts FieldSetOptions) *FieldSet {
+	type level struct {
+		stct      *types.Struct
+		index     []int
+		embedding []*types.Var
+	}
+
+	// Candidates contains every name found, including embedded struct
+	// fields, which are never output but can still shadow or conflict.
+	type candidate struct {
+		field    EffectiveField
+		expanded bool
+	}
+
+	naming := opts.naming()
+
+	var candidates []candidate
+	visited := map[types.Type]bool{}
+	next := []level{{stct: s}}
+
+	for depth := 0; len(next) > 0; depth++ {
+		current := next
+		next = nil
+		levelVisited := map[types.Type]bool{}
+
+		for _, lv := range current {
+			for i := 0; i < lv.stct.NumFields(); i++ {
+				field := lv.stct.Field(i)
+				tag := lv.stct.Tag(i)
+
+				index := make([]int, len(lv.index)+1)
+				copy(index, lv.index)
+				index[len(lv.index)] = i
+
+				ef := EffectiveField{
+					Field:     field,
+					Tag:       tag,
+					Name:      field.Name(),
+					Depth:     depth,
+					Embedding: lv.embedding,
+					Index:     index,
+				}
+
+				embedded := embeddedStruct(field)
+
+				if naming != nil {
+					wire := naming.Resolve(field, ParseStructTag(tag))
+					if wire.Skip {
+						continue
+					}
+					ef.Wire = wire
+					if inline := structOf(field.Type()); wire.Inline && inline != nil {
+						// Inlined fields keep their Go name so they can still
+						// shadow deeper fields.
+						embedded = inline
+					} else {
+						ef.Name, ef.Tagged = wire.Name, wire.Tagged
+						embedded = nil
+					}
+				}
+
+				expanded := false
+				if embedded != nil {
+					key := types.Type(embedded)
+					if named := namedOf(field.Type()); named != nil {
+						key = named
+					}
+					// The same type embedded more than once at the same depth is
+					// expanded each time so its fields will conflict.
+					if !visited[key] {
+						levelVisited[key] = true
+						emb := make([]*types.Var, len(lv.embedding)+1)
+						copy(emb, lv.embedding)
+						emb[len(lv.embedding)] = field
+						next = append(next, level{stct: embedded, index: index, embedding: emb})
+					}
+					expanded = true
+				}
+
+				candidates = append(candidates, candidate{field: ef, expanded: expanded})
+			}
+		}
+
+		for key := range levelVisited {
+			visited[key] = true
+		}
+	}
+
+	// Group by name, then find the dominant field for each name.
+	byName := map[string][]candidate{}
+	var names []string
+	for _, c := range candidates {
+		if _, ok := byName[c.field.Name]; !ok {
+			names = append(names, c.field.Name)
+		}
+		byName[c.field.Name] = append(byName[c.field.Name], c)
+	}
+
+	fs := &FieldSet{}
+	for _, name := range names {
+		cands := byName[name]
+
+		minDepth := cands[0].field.Depth
+		var dominant []candidate
+		for _, c := range cands {
+			if c.field.Depth < minDepth {
+				minDepth, dominant = c.field.Depth, nil
+			}
+			if c.field.Depth == minDepth {
+				dominant = append(dominant, c)
+			}
+		}
+
+		if len(dominant) > 1 && naming != nil {
+			var tagged []candidate
+			for _, c := range dominant {
+				if c.field.Tagged {
+					tagged = append(tagged, c)
+				}
+			}
+			if len(tagged) == 1 {
+				dominant = tagged
+			}
+		}
+
+		if len(dominant) > 1 {
+			conflict := FieldConflict{Name: name, Depth: minDepth}
+			for _, c := range dominant {
+				conflict.Fields = append(conflict.Fields, c.field)
+			}
+			fs.Conflicts = append(fs.Conflicts, conflict)
+			continue
+		}
+
+		if !dominant[0].expanded {
+			fs.Fields = append(fs.Fields, dominant[0].field)
+		}
+	}
+
+	sort.Slice(fs.Fields, func(i, j int) bool {
+		return indexBefore(fs.Fields[i].Index, fs.Fields[j].Index)
+	})
+	sort.Slice(fs.Conflicts, func(i, j int) bool {
+		return fs.Conflicts[i].Name < fs.Conflicts[j].Name
+	})
+	return fs
+}
+
+// EffectiveFields calculates the effective, flattened field list for the
+// struct type tn. See the EffectiveFields function for details.
+func (t *TypePackageSet) EffectiveFields(tn TypeName, opts FieldSetOptions) (*FieldSet, error) {
+	tobj := t.FindObject(tn)
+	if tobj == nil {
+		return nil, fmt.Errorf("type %s not found", tn)
+	}
+	stct, ok := tobj.Type().Underlying().(*types.Struct)
+	if !ok {
+		return nil, fmt.Errorf("type %s is not a struct", tn)
+	}
+	return EffectiveFields(stct, opts), nil
+}
+
+// embeddedStruct returns the struct embedded by field, or nil if field is not
+// an embedded struct or pointer to a struct.
+func embeddedStruct(field *types.Var) *types.Struct {
+	if !field.Anonymous() {
+		return nil
+	}
+	return structOf(field.Type())
+}
+
+// structOf returns the struct underlying typ, or the type typ points to.
+func structOf(typ types.Type) *types.Struct {
+	if ptr, ok := typ.(*types.Pointer); ok {
+		typ = ptr.Elem()
+	}
+	stct, _ := typ.Underlying().(*types.Struct)
+	return stct
+}
+
+func namedOf(typ types.Type) *types.Named {
+	if ptr, ok := typ.(*types.Pointer); ok {
+		typ = ptr.Elem()
+	}
+	named, _ := typ.(*types.Named)
+	return named
+}
+
+func indexBefore(a, b []int) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return len(a) < len(b)
+}
@@ -0,0 +1,109 @@
+package structer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func fieldSetNames(fs *FieldSet) (names []string, conflicts []string) {
+	for _, f := range fs.Fields {
+		names = append(names, f.Name)
+	}
+	for _, c := range fs.Conflicts {
+		conflicts = append(conflicts, c.Name)
+	}
+	return
+}
+
+func TestEffectiveFields(t *testing.T) {
+	pkg := "github.com/shabbyrobe/structer/testpkg/promote"
+	tpset := NewTypePackageSet()
+	if _, err := tpset.Import(pkg); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		typ       string
+		tagKey    string
+		names     []string
+		conflicts []string
+	}{
+		{"Simple", "", []string{"ID", "Name"}, nil},
+		{"Ambiguous", "", []string{"ID", "Extra"}, []string{"Name"}},
+		{"Tagged", "", []string{"ID", "Extra", "Title", "Skipped", "hidden"}, []string{"Name"}},
+		{"Tagged", "json", []string{"ID", "other", "Name"}, nil},
+		{"TagWins", "", nil, []string{"X"}},
+		{"TagWins", "json", []string{"X"}, nil},
+		{"Node", "", []string{"Val"}, nil},
+	} {
+		fs, err := tpset.EffectiveFields(NewTypeName(pkg, tc.typ), FieldSetOptions{TagKey: tc.tagKey})
+		if err != nil {
+			t.Fatal(err)
+		}
+		names, conflicts := fieldSetNames(fs)
+		if !reflect.DeepEqual(tc.names, names) {
+			t.Fatalf("%s %q: names %v != %v", tc.typ, tc.tagKey, names, tc.names)
+		}
+		if !reflect.DeepEqual(tc.conflicts, conflicts) {
+			t.Fatalf("%s %q: conflicts %v != %v", tc.typ, tc.tagKey, conflicts, tc.conflicts)
+		}
+	}
+}
+
+func TestEffectiveFieldsEmbedding(t *testing.T) {
+	pkg := "github.com/shabbyrobe/structer/testpkg/promote"
+	tpset := NewTypePackageSet()
+	if _, err := tpset.Import(pkg); err != nil {
+		t.Fatal(err)
+	}
+	fs, err := tpset.EffectiveFields(NewTypeName(pkg, "TagWins"), FieldSetOptions{TagKey: "json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	x, ok := fs.Lookup("X")
+	if !ok {
+		t.Fatal()
+	}
+	if x.Depth != 1 || !x.Tagged || len(x.Embedding) != 1 || x.Embedding[0].Name() != "TaggedA" {
+		t.Fatal(x)
+	}
+	if !reflect.DeepEqual([]int{0, 0}, x.Index) {
+		t.Fatal(x.Index)
+	}
+}
+
+func TestWalkPromoted(t *testing.T) {
+	pkg := "github.com/shabbyrobe/structer/testpkg/promote"
+	tpset := NewTypePackageSet()
+	if _, err := tpset.Import(pkg); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		typ   string
+		exprs []string
+	}{
+		{"Simple", []string{"v.Base.ID", "v.Name"}},
+		{"Ambiguous", []string{"v.Base.ID", "v.Other.Extra"}},
+	} {
+		tn := NewTypeName(pkg, tc.typ)
+		typ := tpset.MustFindObject(tn).Type().Underlying()
+
+		var exprs []string
+		vis := &PartialTypeVisitor{
+			EnterFieldFunc: func(ctx WalkContext, s StructInfo, field FieldInfo, tag StructTag) error {
+				exprs = append(exprs, ctx.Path().Expr("v"))
+				if len(field.Embedding) != 1 && field.Name() != "Name" {
+					t.Fatalf("unexpected embedding for %s", field.Name())
+				}
+				return nil
+			},
+		}
+		if err := Walk(tn, typ, vis, WalkPromoted()); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(tc.exprs, exprs) {
+			t.Fatalf("%s: %v != %v", tc.typ, exprs, tc.exprs)
+		}
+	}
+}
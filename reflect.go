@@ -0,0 +1,383 @@
+package structer
+
+import (
+	"go/types"
+	"path"
+	"reflect"
+	"runtime"
+)
+
+// interface check
+var _ TypeVisitor = &reflectVisitor{}
+
+// ReflectWalkContext is passed to visitors by WalkReflect in place of a plain
+// WalkContext. It allows a visitor to find the reflect.Type that corresponds
+// to each types.Type it is given:
+//
+//	func (v *visitor) EnterStruct(ctx structer.WalkContext, s structer.StructInfo) error {
+//		if rctx, ok := ctx.(structer.ReflectWalkContext); ok {
+//			rt := rctx.ReflectOf(s.Struct)
+//			...
+//		}
+//	}
+//
+type ReflectWalkContext interface {
+	WalkContext
+
+	// ReflectStack is the same as Stack, but contains reflect.Types.
+	ReflectStack() []reflect.Type
+
+	// ReflectParent is the same as Parent, but returns a reflect.Type.
+	ReflectParent() reflect.Type
+
+	// ReflectOf returns the reflect.Type that t was converted from, or nil if
+	// t was not encountered during the WalkReflect.
+	ReflectOf(t types.Type) reflect.Type
+}
+
+// WalkReflect walks a reflect.Type using the same visitor and emits the same
+// sequence of events as Walk would for the equivalent types.Type. This allows
+// a visitor written for a code generator to be reused at runtime, i.e. to check
+// that the compiled type matches the type seen by the generator.
+//
+// The reflect.Type is converted to a types.Type using ReflectTypeOf before it
+// is walked. If rt is a named type, its underlying type is walked, the same
+// as the other callers of Walk in this package. The visitor is passed a
+// ReflectWalkContext.
+//
+// Layouts are calculated using the sizes for runtime.GOARCH unless WalkSizes
+// is passed.
+//
+// There are some differences to Walk that can't be avoided: reflect does not
+// record which interfaces were embedded in an interface, so the methods of
+// embedded interfaces are visited using VisitMethod rather than VisitEmbedded,
+// and no source positions or documentation are available.
+//
+func WalkReflect(rt reflect.Type, visitor TypeVisitor, opts ...WalkOption) error {
+	conv := newReflectConverter()
+	typ := conv.convert(rt, nil)
+	if rt.Name() != "" {
+		typ = typ.Underlying()
+	}
+
+	if sizes, err := SizesFor(runtime.GOARCH); err == nil {
+		opts = append([]WalkOption{WalkSizes(sizes)}, opts...)
+	}
+	rv := &reflectVisitor{visitor: visitor, conv: conv}
+	return Walk(ReflectTypeName(rt), typ, rv, opts...)
+}
+
+// ReflectTypeName returns the TypeName of a reflect.Type. Predeclared types
+// and types without a name, i.e. "[]int", are returned as builtins.
+func ReflectTypeName(rt reflect.Type) TypeName {
+	if rt.Name() == "" || rt.PkgPath() == "" {
+		return NewBuiltinType(rt.String())
+	}
+	return NewTypeName(rt.PkgPath(), rt.Name())
+}
+
+// ReflectTypeOf converts a reflect.Type into a types.Type. Named types are
+// converted into *types.Named, but only the methods of interfaces are
+// converted; the method sets of other named types are left empty.
+func ReflectTypeOf(rt reflect.Type) types.Type {
+	return newReflectConverter().convert(rt, nil)
+}
+
+var reflectBasicKinds = map[reflect.Kind]types.BasicKind{
+	reflect.Bool:          types.Bool,
+	reflect.Int:           types.Int,
+	reflect.Int8:          types.Int8,
+	reflect.Int16:         types.Int16,
+	reflect.Int32:         types.Int32,
+	reflect.Int64:         types.Int64,
+	reflect.Uint:          types.Uint,
+	reflect.Uint8:         types.Uint8,
+	reflect.Uint16:        types.Uint16,
+	reflect.Uint32:        types.Uint32,
+	reflect.Uint64:        types.Uint64,
+	reflect.Uintptr:       types.Uintptr,
+	reflect.Float32:       types.Float32,
+	reflect.Float64:       types.Float64,
+	reflect.Complex64:     types.Complex64,
+	reflect.Complex128:    types.Complex128,
+	reflect.String:        types.String,
+	reflect.UnsafePointer: types.UnsafePointer,
+}
+
+type reflectConverter struct {
+	types   map[reflect.Type]types.Type
+	reflect map[types.Type]reflect.Type
+	pkgs    map[string]*types.Package
+}
+
+func newReflectConverter() *reflectConverter {
+	return &reflectConverter{
+		types:   map[reflect.Type]types.Type{},
+		reflect: map[types.Type]reflect.Type{},
+		pkgs:    map[string]*types.Package{},
+	}
+}
+
+// pkg returns the package for pkgPath. reflect does not record package
+// names, so the last element of the path is used.
+func (c *reflectConverter) pkg(pkgPath string) *types.Package {
+	if pkgPath == "" {
+		return nil
+	}
+	pkg := c.pkgs[pkgPath]
+	if pkg == nil {
+		pkg = types.NewPackage(pkgPath, path.Base(pkgPath))
+		c.pkgs[pkgPath] = pkg
+	}
+	return pkg
+}
+
+// convert converts rt to a types.Type. pkg is the package that declared the
+// closest enclosing named type, which is used for exported struct fields and
+// interface methods as reflect only records the package of unexported names.
+func (c *reflectConverter) convert(rt reflect.Type, pkg *types.Package) types.Type {
+	if t, ok := c.types[rt]; ok {
+		return t
+	}
+
+	var t types.Type
+	if kind, ok := reflectBasicKinds[rt.Kind()]; ok && (rt.PkgPath() == "" || rt.Kind() == reflect.UnsafePointer) {
+		t = types.Typ[kind]
+
+	} else if rt.Name() != "" && rt.PkgPath() == "" {
+		// Predeclared named types that aren't basic, i.e. "error".
+		t = types.Universe.Lookup(rt.Name()).Type()
+
+	} else if rt.Name() != "" {
+		npkg := c.pkg(rt.PkgPath())
+		named := types.NewNamed(types.NewTypeName(0, npkg, rt.Name(), nil), nil, nil)
+
+		// Register the named type before converting the underlying type to
+		// support recursive types.
+		c.types[rt], c.reflect[named] = named, rt
+		u := c.underlying(rt, npkg)
+		named.SetUnderlying(u)
+
+		// Walk is usually passed the underlying type of a named type, so the
+		// underlying type maps back to the named reflect.Type too.
+		if _, ok := c.reflect[u]; !ok {
+			c.reflect[u] = rt
+		}
+		return named
+
+	} else {
+		t = c.underlying(rt, pkg)
+	}
+
+	c.types[rt] = t
+	if _, ok := c.reflect[t]; !ok {
+		c.reflect[t] = rt
+	}
+	return t
+}
+
+func (c *reflectConverter) underlying(rt reflect.Type, pkg *types.Package) types.Type {
+	if kind, ok := reflectBasicKinds[rt.Kind()]; ok {
+		return types.Typ[kind]
+	}
+
+	switch rt.Kind() {
+	case reflect.Array:
+		return types.NewArray(c.convert(rt.Elem(), pkg), int64(rt.Len()))
+
+	case reflect.Slice:
+		return types.NewSlice(c.convert(rt.Elem(), pkg))
+
+	case reflect.Ptr:
+		return types.NewPointer(c.convert(rt.Elem(), pkg))
+
+	case reflect.Map:
+		return types.NewMap(c.convert(rt.Key(), pkg), c.convert(rt.Elem(), pkg))
+
+	case reflect.Chan:
+		dir := types.SendRecv
+		switch rt.ChanDir() {
+		case reflect.SendDir:
+			dir = types.SendOnly
+		case reflect.RecvDir:
+			dir = types.RecvOnly
+		}
+		return types.NewChan(dir, c.convert(rt.Elem(), pkg))
+
+	case reflect.Func:
+		return c.signature(rt, pkg)
+
+	case reflect.Interface:
+		methods := make([]*types.Func, rt.NumMethod())
+		for i := range methods {
+			m := rt.Method(i)
+			mpkg := pkg
+			if m.PkgPath != "" {
+				mpkg = c.pkg(m.PkgPath)
+			}
+			methods[i] = types.NewFunc(0, mpkg, m.Name, c.signature(m.Type, pkg))
+		}
+		return types.NewInterfaceType(methods, nil).Complete()
+
+	case reflect.Struct:
+		fields := make([]*types.Var, rt.NumField())
+		tags := make([]string, rt.NumField())
+		for i := range fields {
+			sf := rt.Field(i)
+			fpkg := pkg
+			if sf.PkgPath != "" {
+				fpkg = c.pkg(sf.PkgPath)
+			}
+			fields[i] = types.NewField(0, fpkg, sf.Name, c.convert(sf.Type, pkg), sf.Anonymous)
+			tags[i] = string(sf.Tag)
+		}
+		return types.NewStruct(fields, tags)
+
+	default:
+		return types.Typ[types.Invalid]
+	}
+}
+
+func (c *reflectConverter) signature(rt reflect.Type, pkg *types.Package) *types.Signature {
+	params := make([]*types.Var, rt.NumIn())
+	for i := range params {
+		params[i] = types.NewParam(0, pkg, "", c.convert(rt.In(i), pkg))
+	}
+	results := make([]*types.Var, rt.NumOut())
+	for i := range results {
+		results[i] = types.NewParam(0, pkg, "", c.convert(rt.Out(i), pkg))
+	}
+	return types.NewSignature(nil, types.NewTuple(params...), types.NewTuple(results...), rt.IsVariadic())
+}
+
+type reflectWalkContext struct {
+	WalkContext
+	conv *reflectConverter
+}
+
+func (ctx *reflectWalkContext) ReflectOf(t types.Type) reflect.Type {
+	return ctx.conv.reflect[t]
+}
+
+func (ctx *reflectWalkContext) ReflectParent() reflect.Type {
+	return ctx.ReflectOf(ctx.Parent())
+}
+
+func (ctx *reflectWalkContext) ReflectStack() []reflect.Type {
+	stack := ctx.Stack()
+	out := make([]reflect.Type, len(stack))
+	for i, t := range stack {
+		out[i] = ctx.ReflectOf(t)
+	}
+	return out
+}
+
+// reflectVisitor passes a ReflectWalkContext to the wrapped visitor.
+type reflectVisitor struct {
+	visitor TypeVisitor
+	conv    *reflectConverter
+}
+
+func (r *reflectVisitor) ctx(ctx WalkContext) WalkContext {
+	return &reflectWalkContext{WalkContext: ctx, conv: r.conv}
+}
+
+func (r *reflectVisitor) EnterStruct(ctx WalkContext, s StructInfo) error {
+	return r.visitor.EnterStruct(r.ctx(ctx), s)
+}
+
+func (r *reflectVisitor) LeaveStruct(ctx WalkContext, s StructInfo) error {
+	return r.visitor.LeaveStruct(r.ctx(ctx), s)
+}
+
+func (r *reflectVisitor) EnterField(ctx WalkContext, s StructInfo, field FieldInfo, tag StructTag) error {
+	return r.visitor.EnterField(r.ctx(ctx), s, field, tag)
+}
+
+func (r *reflectVisitor) LeaveField(ctx WalkContext, s StructInfo, field FieldInfo, tag StructTag) error {
+	return r.visitor.LeaveField(r.ctx(ctx), s, field, tag)
+}
+
+func (r *reflectVisitor) EnterMap(ctx WalkContext, ft *types.Map) error {
+	return r.visitor.EnterMap(r.ctx(ctx), ft)
+}
+
+func (r *reflectVisitor) LeaveMap(ctx WalkContext, ft *types.Map) error {
+	return r.visitor.LeaveMap(r.ctx(ctx), ft)
+}
+
+func (r *reflectVisitor) EnterMapKey(ctx WalkContext, ft *types.Map, key types.Type) error {
+	return r.visitor.EnterMapKey(r.ctx(ctx), ft, key)
+}
+
+func (r *reflectVisitor) LeaveMapKey(ctx WalkContext, ft *types.Map, key types.Type) error {
+	return r.visitor.LeaveMapKey(r.ctx(ctx), ft, key)
+}
+
+func (r *reflectVisitor) EnterMapElem(ctx WalkContext, ft *types.Map, elem types.Type) error {
+	return r.visitor.EnterMapElem(r.ctx(ctx), ft, elem)
+}
+
+func (r *reflectVisitor) LeaveMapElem(ctx WalkContext, ft *types.Map, elem types.Type) error {
+	return r.visitor.LeaveMapElem(r.ctx(ctx), ft, elem)
+}
+
+func (r *reflectVisitor) EnterPointer(ctx WalkContext, t *types.Pointer) error {
+	return r.visitor.EnterPointer(r.ctx(ctx), t)
+}
+
+func (r *reflectVisitor) LeavePointer(ctx WalkContext, t *types.Pointer) error {
+	return r.visitor.LeavePointer(r.ctx(ctx), t)
+}
+
+func (r *reflectVisitor) EnterSlice(ctx WalkContext, t *types.Slice) error {
+	return r.visitor.EnterSlice(r.ctx(ctx), t)
+}
+
+func (r *reflectVisitor) LeaveSlice(ctx WalkContext, t *types.Slice) error {
+	return r.visitor.LeaveSlice(r.ctx(ctx), t)
+}
+
+func (r *reflectVisitor) EnterArray(ctx WalkContext, t *types.Array) error {
+	return r.visitor.EnterArray(r.ctx(ctx), t)
+}
+
+func (r *reflectVisitor) LeaveArray(ctx WalkContext, t *types.Array) error {
+	return r.visitor.LeaveArray(r.ctx(ctx), t)
+}
+
+func (r *reflectVisitor) VisitBasic(ctx WalkContext, t *types.Basic) error {
+	return r.visitor.VisitBasic(r.ctx(ctx), t)
+}
+
+func (r *reflectVisitor) VisitInvalid(ctx WalkContext, root TypeName, t *types.Basic) error {
+	return r.visitor.VisitInvalid(r.ctx(ctx), root, t)
+}
+
+func (r *reflectVisitor) VisitNamed(ctx WalkContext, t *types.Named) error {
+	return r.visitor.VisitNamed(r.ctx(ctx), t)
+}
+
+func (r *reflectVisitor) EnterInterface(ctx WalkContext, t *types.Interface) error {
+	return r.visitor.EnterInterface(r.ctx(ctx), t)
+}
+
+func (r *reflectVisitor) LeaveInterface(ctx WalkContext, t *types.Interface) error {
+	return r.visitor.LeaveInterface(r.ctx(ctx), t)
+}
+
+func (r *reflectVisitor) VisitMethod(ctx WalkContext, iface *types.Interface, method MethodInfo) error {
+	return r.visitor.VisitMethod(r.ctx(ctx), iface, method)
+}
+
+func (r *reflectVisitor) VisitEmbedded(ctx WalkContext, iface *types.Interface, embedded types.Type) error {
+	return r.visitor.VisitEmbedded(r.ctx(ctx), iface, embedded)
+}
+
+func (r *reflectVisitor) EnterImplementer(ctx WalkContext, iface types.Type, impl Implementer) error {
+	return r.visitor.EnterImplementer(r.ctx(ctx), iface, impl)
+}
+
+func (r *reflectVisitor) LeaveImplementer(ctx WalkContext, iface types.Type, impl Implementer) error {
+	return r.visitor.LeaveImplementer(r.ctx(ctx), iface, impl)
+}
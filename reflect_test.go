@@ -0,0 +1,92 @@
+package structer
+
+import (
+	"go/types"
+	"reflect"
+	"testing"
+)
+
+type testingReflectStruct struct {
+	Name    string `json:"name"`
+	Next    *testingReflectStruct
+	Err     error
+	Tags    map[string][]byte
+	Nested  struct{ A, B int32 }
+	private uint64
+}
+
+func TestWalkReflectMatchesWalk(t *testing.T) {
+	_, tn, tst := getTestingStruct(t, "github.com/shabbyrobe/structer.TestingControlStruct")
+	static := NewTestingVisitor()
+	if err := Walk(tn, tst, static); err != nil {
+		t.Fatal(err)
+	}
+	staticCtl := &controlVisitor{}
+	if err := Walk(tn, tst, staticCtl); err != nil {
+		t.Fatal(err)
+	}
+
+	rt := reflect.TypeOf(TestingControlStruct{})
+	if ReflectTypeName(rt) != tn {
+		t.Fatal(ReflectTypeName(rt))
+	}
+	dynamic := NewTestingVisitor()
+	if err := WalkReflect(rt, dynamic); err != nil {
+		t.Fatal(err)
+	}
+	dynamicCtl := &controlVisitor{}
+	if err := WalkReflect(rt, dynamicCtl); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(static.FieldEvents, dynamic.FieldEvents) {
+		t.Fatalf("%v\n!=\n%v", static.FieldEvents, dynamic.FieldEvents)
+	}
+	if !reflect.DeepEqual(staticCtl.Events, dynamicCtl.Events) {
+		t.Fatalf("%v\n!=\n%v", staticCtl.Events, dynamicCtl.Events)
+	}
+}
+
+func TestWalkReflectContext(t *testing.T) {
+	rt := reflect.TypeOf(testingReflectStruct{})
+
+	var fields []string
+	var parents []reflect.Type
+	var named []string
+	vis := &PartialTypeVisitor{
+		EnterFieldFunc: func(ctx WalkContext, s StructInfo, field FieldInfo, tag StructTag) error {
+			rctx := ctx.(ReflectWalkContext)
+			sf := rctx.ReflectOf(s.Struct).Field(field.Index)
+			if sf.Name != field.Name() || string(sf.Tag) != tag.Raw {
+				t.Fatal(sf, field)
+			}
+			if field.Layout.Offset != int64(sf.Offset) {
+				t.Fatal(field.Name(), field.Layout.Offset, sf.Offset)
+			}
+			fields = append(fields, field.Name())
+			return nil
+		},
+		VisitBasicFunc: func(ctx WalkContext, bt *types.Basic) error {
+			parents = append(parents, ctx.(ReflectWalkContext).ReflectParent())
+			return nil
+		},
+		VisitNamedFunc: func(ctx WalkContext, nt *types.Named) error {
+			named = append(named, nt.String())
+			return nil
+		},
+	}
+	if err := WalkReflect(rt, vis); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual([]string{"Name", "Next", "Err", "Tags", "Nested", "A", "B", "private"}, fields) {
+		t.Fatal(fields)
+	}
+	if !reflect.DeepEqual([]string{"github.com/shabbyrobe/structer.testingReflectStruct", "error"}, named) {
+		t.Fatal(named)
+	}
+	// Name, Tags key, Tags elem, A, B, private
+	if len(parents) != 6 || parents[0] != rt || parents[2] != reflect.TypeOf([]byte{}) || parents[5] != rt {
+		t.Fatal(parents)
+	}
+}
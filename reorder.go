@@ -0,0 +1,300 @@
+package structer
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// FieldOrderReport describes a struct whose size could be reduced by
+// reordering its fields.
+type FieldOrderReport struct {
+	Name     TypeName
+	Position token.Position
+
+	// Layout of the struct as declared.
+	Layout *StructLayout
+
+	// Layout of the struct using the fields in Order.
+	Optimal *StructLayout
+
+	// Order contains the indexes of the struct's fields in the order that
+	// yields the smallest size.
+	Order []int
+
+	// Saved is the number of bytes saved by using Order.
+	Saved int64
+}
+
+// OptimalFieldOrder returns the order of the fields of s, expressed as a list
+// of field indexes, that minimises the size of s for the supplied sizes.
+//
+// Zero-sized fields are placed first (a trailing zero-sized field causes the
+// compiler to add padding), then fields are ordered by decreasing alignment
+// and finally by decreasing size. The sort is stable, so fields that do not
+// need to move will retain their relative order.
+//
+func OptimalFieldOrder(s *types.Struct, sizes types.Sizes) []int {
+	n := s.NumFields()
+	order := make([]int, n)
+	fsizes := make([]int64, n)
+	aligns := make([]int64, n)
+	for i := 0; i < n; i++ {
+		order[i] = i
+		fsizes[i] = sizes.Sizeof(s.Field(i).Type())
+		aligns[i] = sizes.Alignof(s.Field(i).Type())
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := order[i], order[j]
+		if (fsizes[a] == 0) != (fsizes[b] == 0) {
+			return fsizes[a] == 0
+		}
+		if aligns[a] != aligns[b] {
+			return aligns[a] > aligns[b]
+		}
+		return fsizes[a] > fsizes[b]
+	})
+	return order
+}
+
+// ReorderStruct returns a new struct with the fields (and tags) of s in the
+// supplied order.
+func ReorderStruct(s *types.Struct, order []int) (*types.Struct, error) {
+	if len(order) != s.NumFields() {
+		return nil, fmt.Errorf("order has %d fields, struct has %d", len(order), s.NumFields())
+	}
+	seen := make([]bool, len(order))
+	vars := make([]*types.Var, len(order))
+	tags := make([]string, len(order))
+	for i, idx := range order {
+		if idx < 0 || idx >= len(order) || seen[idx] {
+			return nil, fmt.Errorf("invalid field order %v", order)
+		}
+		seen[idx] = true
+		vars[i], tags[i] = s.Field(idx), s.Tag(idx)
+	}
+	return types.NewStruct(vars, tags), nil
+}
+
+// AnalyzeFieldOrder checks every struct type declared in the loaded packages
+// and reports the ones that could be made smaller by reordering their fields.
+// Layouts are calculated using TypePackageSet.Sizes. Only types declared with
+// a struct literal are checked, as those are the ones ReorderFieldsSource can
+// rewrite.
+//
+// Reports are sorted by type name.
+//
+func (t *TypePackageSet) AnalyzeFieldOrder() ([]*FieldOrderReport, error) {
+	sizes, err := t.Sizes()
+	if err != nil {
+		return nil, err
+	}
+
+	var names TypeNames
+	for name, obj := range t.Objects {
+		if _, ok := obj.(*types.TypeName); !ok {
+			continue
+		}
+		if _, ok := obj.Type().Underlying().(*types.Struct); !ok {
+			continue
+		}
+		// Types like "type B A" share the fields of A, so they can only be
+		// reordered by ReorderFieldsSource in A's declaration.
+		ts, ok := t.ASTPackages.FindNodeByPackagePathPos(name.PackagePath, obj.Pos()).(*ast.TypeSpec)
+		if !ok {
+			continue
+		}
+		if _, ok := ts.Type.(*ast.StructType); ok {
+			names = append(names, name)
+		}
+	}
+	names.Sort()
+
+	var reports []*FieldOrderReport
+	for _, name := range names {
+		obj := t.Objects[name]
+		stct := obj.Type().Underlying().(*types.Struct)
+
+		order := OptimalFieldOrder(stct, sizes)
+		optimal, err := ReorderStruct(stct, order)
+		if err != nil {
+			return nil, err
+		}
+
+		report := &FieldOrderReport{
+			Name:     name,
+			Position: t.ASTPackages.FileSet.Position(obj.Pos()),
+			Layout:   NewStructLayout(stct, sizes),
+			Optimal:  NewStructLayout(optimal, sizes),
+			Order:    order,
+		}
+		report.Saved = report.Layout.Size - report.Optimal.Size
+		if report.Saved > 0 {
+			reports = append(reports, report)
+		}
+	}
+	return reports, nil
+}
+
+// ReorderFieldsSource rewrites the declaration of the struct type tn so that
+// its fields appear in the supplied order, returning the complete, formatted
+// contents of the file containing the declaration. The file on disk is not
+// modified.
+//
+// Doc comments, line comments and tags are preserved and move with their
+// fields. Fields declared together, i.e. "X, Y int", are split onto separate
+// lines.
+//
+func (t *TypePackageSet) ReorderFieldsSource(tn TypeName, order []int) (filename string, src []byte, err error) {
+	obj := t.Objects[tn]
+	if obj == nil {
+		return "", nil, fmt.Errorf("could not find def for %s", tn)
+	}
+	stct, ok := obj.Type().Underlying().(*types.Struct)
+	if !ok {
+		return "", nil, fmt.Errorf("type %s is not a struct", tn)
+	}
+	if _, err := ReorderStruct(stct, order); err != nil {
+		return "", nil, err
+	}
+
+	ts, ok := t.ASTPackages.FindNodeByPackagePathPos(tn.PackagePath, obj.Pos()).(*ast.TypeSpec)
+	if !ok {
+		return "", nil, fmt.Errorf("no type spec found for %s", tn)
+	}
+	st, ok := ts.Type.(*ast.StructType)
+	if !ok {
+		return "", nil, fmt.Errorf("type %s is not declared as a struct literal", tn)
+	}
+
+	fset := t.ASTPackages.FileSet
+	filename = fset.Position(st.Pos()).Filename
+	astPkg := t.ASTPackages.Packages[tn.PackagePath]
+	if astPkg == nil {
+		return "", nil, fmt.Errorf("no ast pkg for %s", tn.PackagePath)
+	}
+	contents, ok := astPkg.Contents[filepath.Base(filename)]
+	if !ok {
+		return "", nil, fmt.Errorf("no contents for %s", tn)
+	}
+	var file *ast.File
+	for _, f := range astPkg.AST.Files {
+		if f.Pos() <= st.Pos() && st.End() <= f.End() {
+			file = f
+			break
+		}
+	}
+	if file == nil {
+		return "", nil, fmt.Errorf("no file found for %s", tn)
+	}
+	off := func(pos token.Pos) int { return fset.Position(pos).Offset }
+	text := func(node ast.Node) string { return string(contents[off(node.Pos()):off(node.End())]) }
+
+	// leadingText returns the comments between the offsets from and to,
+	// dropping anything else, like the ";" separating fields declared on a
+	// single line.
+	leadingText := func(from, to int) string {
+		var groups []string
+		for _, cg := range file.Comments {
+			if start := off(cg.Pos()); start >= from && off(cg.End()) <= to {
+				groups = append(groups, text(cg))
+			}
+		}
+		leading := strings.Join(groups, "\n\n")
+		if leading != "" && hasBlankLine(contents[from:to]) {
+			leading = "\n" + leading
+		}
+		return leading
+	}
+
+	// Render each field on its own line, along with any text (i.e. comments)
+	// that precedes it, indexed by its position in the types.Struct.
+	var lines []string
+	prevEnd := off(st.Fields.Opening) + 1
+	for _, field := range st.Fields.List {
+		end := field.End()
+		if field.Comment != nil {
+			end = field.Comment.End()
+		}
+		leading := leadingText(prevEnd, off(field.Pos()))
+		prevEnd = off(end)
+
+		suffix := text(field.Type)
+		if field.Tag != nil {
+			suffix += " " + text(field.Tag)
+		}
+
+		names := field.Names
+		if len(names) == 0 {
+			names = []*ast.Ident{nil}
+		}
+		for i, name := range names {
+			var line string
+			if i == 0 && leading != "" {
+				line = leading + "\n"
+			}
+			if name != nil {
+				line += name.Name + " "
+			}
+			line += suffix
+			if i == 0 && field.Comment != nil {
+				line += " " + text(field.Comment)
+			}
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) != stct.NumFields() {
+		return "", nil, fmt.Errorf("ast for %s has %d fields, expected %d", tn, len(lines), stct.NumFields())
+	}
+	trailer := leadingText(prevEnd, off(st.Fields.Closing))
+
+	var buf bytes.Buffer
+	buf.Write(contents[:off(st.Pos())])
+	buf.WriteString("struct {\n")
+	for i, idx := range order {
+		line := lines[idx]
+		if i == 0 {
+			line = strings.TrimLeft(line, "\n")
+		}
+		buf.WriteString(line)
+		buf.WriteString("\n")
+	}
+	if trailer != "" {
+		buf.WriteString(trailer)
+		buf.WriteString("\n")
+	}
+	buf.WriteString("}")
+	buf.Write(contents[off(st.End()):])
+
+	src, err = format.Source(buf.Bytes())
+	if err != nil {
+		return "", nil, err
+	}
+	return filename, src, nil
+}
+
+// hasBlankLine reports whether the whitespace at the start of src contains
+// an empty line.
+func hasBlankLine(src []byte) bool {
+	lines := 0
+	for _, c := range src {
+		switch c {
+		case '\n':
+			lines++
+			if lines > 1 {
+				return true
+			}
+		case ' ', '\t', '\r':
+		default:
+			return false
+		}
+	}
+	return false
+}
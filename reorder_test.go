@@ -0,0 +1,121 @@
+package structer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAnalyzeFieldOrder(t *testing.T) {
+	pkg := "github.com/shabbyrobe/structer/testpkg/reorder"
+	tpset := NewTypePackageSet()
+	tpset.Config.GOARCH = "amd64"
+	if _, err := tpset.Import(pkg); err != nil {
+		t.Fatal(err)
+	}
+
+	reports, err := tpset.AnalyzeFieldOrder()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Inline would be reordered by OptimalFieldOrder, but it is the same
+	// size either way so it should not be reported. Derived is not declared
+	// with a struct literal, so it can't be rewritten and is not reported.
+	var names []string
+	for _, report := range reports {
+		names = append(names, report.Name.Name)
+	}
+	if !reflect.DeepEqual([]string{"Separated", "SingleLine", "Wasteful"}, names) {
+		t.Fatal(names)
+	}
+
+	wasteful := reports[2]
+	if wasteful.Name != NewTypeName(pkg, "Wasteful") {
+		t.Fatal(wasteful.Name)
+	}
+	if wasteful.Layout.Size != 24 || wasteful.Optimal.Size != 16 || wasteful.Saved != 8 {
+		t.Fatalf("%d %d %d", wasteful.Layout.Size, wasteful.Optimal.Size, wasteful.Saved)
+	}
+	if !reflect.DeepEqual([]int{1, 4, 0, 2, 3}, wasteful.Order) {
+		t.Fatal(wasteful.Order)
+	}
+}
+
+func TestReorderFieldsSource(t *testing.T) {
+	pkg := "github.com/shabbyrobe/structer/testpkg/reorder"
+	tpset := NewTypePackageSet()
+	if _, err := tpset.Import(pkg); err != nil {
+		t.Fatal(err)
+	}
+
+	file, src, err := tpset.ReorderFieldsSource(NewTypeName(pkg, "Wasteful"), []int{1, 4, 0, 2, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(file, "reorder.go") {
+		t.Fatal(file)
+	}
+
+	expected := "" +
+		"// Wasteful wastes bytes.\n" +
+		"type Wasteful struct {\n" +
+		"\tB int64 // B is an int64\n" +
+		"\n" +
+		"\t// E is an int32.\n" +
+		"\tE int32 `json:\"e,omitempty\"`\n" +
+		"\t// A is a bool.\n" +
+		"\tA bool `json:\"a\"`\n" +
+		"\tC bool\n" +
+		"\tD bool\n" +
+		"\n" +
+		"\t// Trailing comment\n" +
+		"}\n"
+	if !strings.Contains(string(src), expected) {
+		t.Fatalf("unexpected source:\n%s", src)
+	}
+	if !strings.Contains(string(src), "type Optimal struct {\n\tB int64\n") {
+		t.Fatalf("unexpected source:\n%s", src)
+	}
+}
+
+func TestReorderFieldsSourceInvalidOrder(t *testing.T) {
+	pkg := "github.com/shabbyrobe/structer/testpkg/reorder"
+	tpset := NewTypePackageSet()
+	if _, err := tpset.Import(pkg); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := tpset.ReorderFieldsSource(NewTypeName(pkg, "Optimal"), []int{0, 0, 1}); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestReorderFieldsSourceSeparators(t *testing.T) {
+	pkg := "github.com/shabbyrobe/structer/testpkg/reorder"
+	tpset := NewTypePackageSet()
+	tpset.Config.GOARCH = "amd64"
+	if _, err := tpset.Import(pkg); err != nil {
+		t.Fatal(err)
+	}
+	reports, err := tpset.AnalyzeFieldOrder()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"SingleLine": "type SingleLine struct {\n\tB int64\n\tA bool\n\tC bool\n}\n",
+		"Separated":  "type Separated struct {\n\tB int64 // B is an int64\n\tA bool\n\t// C is a bool.\n\tC bool\n}\n",
+	}
+	for _, report := range reports {
+		want, ok := expected[report.Name.Name]
+		if !ok {
+			continue
+		}
+		_, src, err := tpset.ReorderFieldsSource(report.Name, report.Order)
+		if err != nil {
+			t.Fatal(report.Name, err)
+		}
+		if !strings.Contains(string(src), want) {
+			t.Fatalf("unexpected source:\n%s", src)
+		}
+	}
+}
@@ -0,0 +1,412 @@
+package structer
+
+import (
+	"fmt"
+	"go/types"
+	"sort"
+)
+
+// SchemaVersion is the version of the Schema JSON representation produced by
+// this package.
+const SchemaVersion = 1
+
+// SchemaKind identifies the kind of a SchemaType.
+type SchemaKind string
+
+const (
+	SchemaBasic     SchemaKind = "basic"
+	SchemaStruct    SchemaKind = "struct"
+	SchemaMap       SchemaKind = "map"
+	SchemaList      SchemaKind = "list"
+	SchemaArray     SchemaKind = "array"
+	SchemaOptional  SchemaKind = "optional"
+	SchemaRef       SchemaKind = "ref"
+	SchemaEnum      SchemaKind = "enum"
+	SchemaInterface SchemaKind = "interface"
+	SchemaInvalid   SchemaKind = "invalid"
+)
+
+// Schema is a serialisable intermediate representation of a set of types,
+// intended to be consumed by code generators and non-Go tools. It can be
+// encoded to and decoded from JSON using encoding/json.
+type Schema struct {
+	Version int `json:"version"`
+
+	// Roots contains the names of the types the schema was built from.
+	Roots []string `json:"roots"`
+
+	// Types contains a declaration for every named type reachable from Roots,
+	// sorted by name.
+	Types []*SchemaDecl `json:"types"`
+}
+
+// Lookup returns the declaration for the named type, or nil if there is no
+// declaration.
+func (s *Schema) Lookup(name string) *SchemaDecl {
+	for _, decl := range s.Types {
+		if decl.Name == name {
+			return decl
+		}
+	}
+	return nil
+}
+
+// SchemaDecl is the declaration of a named type.
+type SchemaDecl struct {
+	// Name is the full name of the type, i.e. "example.com/pkg.Type". It is
+	// used by SchemaType.Ref.
+	Name    string      `json:"name"`
+	Package string      `json:"package"`
+	Doc     string      `json:"doc,omitempty"`
+	Type    *SchemaType `json:"type"`
+}
+
+// SchemaType is a node in the tree describing a type. Which fields are used
+// depends on Kind.
+type SchemaType struct {
+	Kind SchemaKind `json:"kind"`
+
+	// Basic is the name of the basic type for SchemaBasic, or the underlying
+	// basic type for SchemaEnum.
+	Basic string `json:"basic,omitempty"`
+
+	// Ref is the name of a SchemaDecl for SchemaRef. References to types
+	// that are not declared in the Schema, i.e. types from the standard
+	// library, are still SchemaRef but have no SchemaDecl.
+	Ref string `json:"ref,omitempty"`
+
+	// Len is the length of a SchemaArray.
+	Len int64 `json:"len,omitempty"`
+
+	// Key is the key of a SchemaMap.
+	Key *SchemaType `json:"key,omitempty"`
+
+	// Elem is the elem of a SchemaMap, SchemaList, SchemaArray or
+	// SchemaOptional.
+	Elem *SchemaType `json:"elem,omitempty"`
+
+	Fields []*SchemaField `json:"fields,omitempty"`
+
+	// Values of a SchemaEnum. Closed is true if the values are the only
+	// valid values for the type, i.e. the type is an enum according to the
+	// TypePackageSet's EnumPolicy.
+	Values []*SchemaEnumValue `json:"values,omitempty"`
+	Closed bool               `json:"closed,omitempty"`
+
+	// Methods, embedded interfaces and known implementers of a
+	// SchemaInterface. Embeds are SchemaRefs. Implementers contains the
+	// names of every type in the TypePackageSet that implements the
+	// interface.
+	Methods      []*SchemaMethod `json:"methods,omitempty"`
+	Embeds       []*SchemaType   `json:"embeds,omitempty"`
+	Implementers []string        `json:"implementers,omitempty"`
+}
+
+// SchemaField is a field of a SchemaStruct.
+type SchemaField struct {
+	Name     string      `json:"name"`
+	Doc      string      `json:"doc,omitempty"`
+	Tag      string      `json:"tag,omitempty"`
+	Embedded bool        `json:"embedded,omitempty"`
+	Type     *SchemaType `json:"type"`
+}
+
+// SchemaEnumValue is a constant of a SchemaEnum. Value is rendered using
+// constant.Value.ExactString, so strings are quoted.
+type SchemaEnumValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	Doc   string `json:"doc,omitempty"`
+}
+
+// SchemaMethod is a method of a SchemaInterface. Signature is rendered
+// relative to the package that declares the interface.
+type SchemaMethod struct {
+	Name      string `json:"name"`
+	Doc       string `json:"doc,omitempty"`
+	Signature string `json:"signature"`
+}
+
+// Schema builds a Schema containing the roots and every named type reachable
+// from them, including the known implementers of any interfaces.
+//
+// Only types that have been imported from user packages are declared; named
+// types from system and vendor packages, like time.Time, only appear as
+// SchemaRefs. Named basic types with constants declared in the same package
+// are declared as a SchemaEnum.
+//
+func (t *TypePackageSet) Schema(roots ...TypeName) (*Schema, error) {
+	schema := &Schema{Version: SchemaVersion}
+	seen := map[TypeName]bool{}
+	queue := append(TypeNames{}, roots...)
+	for _, root := range roots {
+		schema.Roots = append(schema.Roots, root.String())
+		seen[root] = true
+	}
+
+	for len(queue) > 0 {
+		tn := queue[0]
+		queue = queue[1:]
+
+		obj, ok := t.FindObject(tn).(*types.TypeName)
+		if !ok {
+			if t.Kinds[tn.PackagePath] == UserPackage {
+				return nil, fmt.Errorf("type %s not found", tn)
+			}
+			continue
+		}
+
+		sb := &schemaBuilder{tpset: t}
+		decl, err := sb.decl(tn, obj)
+		if err != nil {
+			return nil, err
+		}
+		schema.Types = append(schema.Types, decl)
+
+		for _, ref := range sb.refs {
+			if !seen[ref] {
+				seen[ref] = true
+				queue = append(queue, ref)
+			}
+		}
+	}
+
+	sort.Slice(schema.Types, func(i, j int) bool {
+		return schema.Types[i].Name < schema.Types[j].Name
+	})
+	return schema, nil
+}
+
+// schemaFrame is an open container node in the tree being built by
+// schemaBuilder. Exactly one of typ and field is set.
+type schemaFrame struct {
+	typ   *SchemaType
+	field *SchemaField
+
+	// key is true between EnterMapKey and LeaveMapKey.
+	key bool
+}
+
+// schemaBuilder builds the SchemaType for a single named type using Walk.
+type schemaBuilder struct {
+	tpset  *TypePackageSet
+	pkg    *types.Package
+	frames []*schemaFrame
+	result *SchemaType
+
+	// refs contains the named types encountered, in order.
+	refs TypeNames
+}
+
+// interface check
+var _ TypeVisitor = &schemaBuilder{}
+
+func (sb *schemaBuilder) decl(tn TypeName, obj *types.TypeName) (*SchemaDecl, error) {
+	sb.pkg = obj.Pkg()
+	decl := &SchemaDecl{Name: tn.String(), Package: tn.PackagePath}
+
+	var err error
+	if decl.Doc, err = sb.tpset.TypeDoc(tn); err != nil {
+		return nil, err
+	}
+
+	if basic, ok := obj.Type().Underlying().(*types.Basic); ok {
+		consts, err := sb.tpset.ExtractConsts(tn, false)
+		if err != nil {
+			return nil, err
+		}
+		if len(consts.Values) > 0 {
+			decl.Type = &SchemaType{Kind: SchemaEnum, Basic: basic.Name(), Closed: consts.IsEnum}
+			for _, cv := range consts.Values {
+				decl.Type.Values = append(decl.Type.Values, &SchemaEnumValue{
+					Name:  cv.Name.Name,
+					Value: cv.Value.ExactString(),
+					Doc:   cv.Doc,
+				})
+			}
+			return decl, nil
+		}
+	}
+
+	if err := Walk(tn, obj.Type().Underlying(), sb, WalkTypePackageSet(sb.tpset)); err != nil {
+		return nil, err
+	}
+	decl.Type = sb.result
+
+	if decl.Type.Kind == SchemaInterface {
+		impls, err := sb.tpset.FindImplementers(tn)
+		if err != nil {
+			return nil, err
+		}
+		for _, impl := range impls.SortedKeys() {
+			decl.Type.Implementers = append(decl.Type.Implementers, impl.String())
+			sb.refs = append(sb.refs, impl)
+		}
+	}
+	return decl, nil
+}
+
+func (sb *schemaBuilder) top() *schemaFrame {
+	if len(sb.frames) == 0 {
+		return nil
+	}
+	return sb.frames[len(sb.frames)-1]
+}
+
+// attach adds node to the innermost open container.
+func (sb *schemaBuilder) attach(node *SchemaType) {
+	top := sb.top()
+	switch {
+	case top == nil:
+		sb.result = node
+	case top.field != nil:
+		top.field.Type = node
+	case top.typ.Kind == SchemaMap && top.key:
+		top.typ.Key = node
+	default:
+		top.typ.Elem = node
+	}
+}
+
+func (sb *schemaBuilder) open(node *SchemaType) error {
+	sb.attach(node)
+	sb.frames = append(sb.frames, &schemaFrame{typ: node})
+	return nil
+}
+
+func (sb *schemaBuilder) close() error {
+	sb.frames = sb.frames[:len(sb.frames)-1]
+	return nil
+}
+
+func (sb *schemaBuilder) ref(t *types.Named) *SchemaType {
+	tn := ExtractTypeName(t)
+	sb.refs = append(sb.refs, tn)
+	return &SchemaType{Kind: SchemaRef, Ref: tn.String()}
+}
+
+func (sb *schemaBuilder) EnterStruct(ctx WalkContext, s StructInfo) error {
+	return sb.open(&SchemaType{Kind: SchemaStruct})
+}
+
+func (sb *schemaBuilder) LeaveStruct(ctx WalkContext, s StructInfo) error {
+	return sb.close()
+}
+
+func (sb *schemaBuilder) EnterField(ctx WalkContext, s StructInfo, field FieldInfo, tag StructTag) error {
+	sf := &SchemaField{Name: field.Name(), Tag: tag.Raw, Embedded: field.Anonymous()}
+	if field.Pkg() != nil && field.Pos().IsValid() {
+		doc, err := sb.tpset.ASTPackages.FindComment(field.Pkg().Path(), field.Pos())
+		if err != nil {
+			return err
+		}
+		sf.Doc = doc
+	}
+	top := sb.top()
+	top.typ.Fields = append(top.typ.Fields, sf)
+	sb.frames = append(sb.frames, &schemaFrame{field: sf})
+	return nil
+}
+
+func (sb *schemaBuilder) LeaveField(ctx WalkContext, s StructInfo, field FieldInfo, tag StructTag) error {
+	return sb.close()
+}
+
+func (sb *schemaBuilder) EnterMap(ctx WalkContext, ft *types.Map) error {
+	return sb.open(&SchemaType{Kind: SchemaMap})
+}
+
+func (sb *schemaBuilder) LeaveMap(ctx WalkContext, ft *types.Map) error {
+	return sb.close()
+}
+
+func (sb *schemaBuilder) EnterMapKey(ctx WalkContext, ft *types.Map, key types.Type) error {
+	sb.top().key = true
+	return nil
+}
+
+func (sb *schemaBuilder) LeaveMapKey(ctx WalkContext, ft *types.Map, key types.Type) error {
+	sb.top().key = false
+	return nil
+}
+
+func (sb *schemaBuilder) EnterMapElem(ctx WalkContext, ft *types.Map, elem types.Type) error {
+	return nil
+}
+
+func (sb *schemaBuilder) LeaveMapElem(ctx WalkContext, ft *types.Map, elem types.Type) error {
+	return nil
+}
+
+func (sb *schemaBuilder) EnterPointer(ctx WalkContext, t *types.Pointer) error {
+	return sb.open(&SchemaType{Kind: SchemaOptional})
+}
+
+func (sb *schemaBuilder) LeavePointer(ctx WalkContext, t *types.Pointer) error {
+	return sb.close()
+}
+
+func (sb *schemaBuilder) EnterSlice(ctx WalkContext, t *types.Slice) error {
+	return sb.open(&SchemaType{Kind: SchemaList})
+}
+
+func (sb *schemaBuilder) LeaveSlice(ctx WalkContext, t *types.Slice) error {
+	return sb.close()
+}
+
+func (sb *schemaBuilder) EnterArray(ctx WalkContext, t *types.Array) error {
+	return sb.open(&SchemaType{Kind: SchemaArray, Len: t.Len()})
+}
+
+func (sb *schemaBuilder) LeaveArray(ctx WalkContext, t *types.Array) error {
+	return sb.close()
+}
+
+func (sb *schemaBuilder) VisitBasic(ctx WalkContext, t *types.Basic) error {
+	sb.attach(&SchemaType{Kind: SchemaBasic, Basic: t.Name()})
+	return nil
+}
+
+func (sb *schemaBuilder) VisitInvalid(ctx WalkContext, root TypeName, t *types.Basic) error {
+	sb.attach(&SchemaType{Kind: SchemaInvalid})
+	return nil
+}
+
+func (sb *schemaBuilder) VisitNamed(ctx WalkContext, t *types.Named) error {
+	sb.attach(sb.ref(t))
+	return nil
+}
+
+func (sb *schemaBuilder) EnterInterface(ctx WalkContext, t *types.Interface) error {
+	return sb.open(&SchemaType{Kind: SchemaInterface})
+}
+
+func (sb *schemaBuilder) LeaveInterface(ctx WalkContext, t *types.Interface) error {
+	return sb.close()
+}
+
+func (sb *schemaBuilder) VisitMethod(ctx WalkContext, iface *types.Interface, method MethodInfo) error {
+	top := sb.top()
+	top.typ.Methods = append(top.typ.Methods, &SchemaMethod{
+		Name:      method.Name,
+		Doc:       method.Doc,
+		Signature: types.TypeString(method.Signature, types.RelativeTo(sb.pkg)),
+	})
+	return nil
+}
+
+func (sb *schemaBuilder) VisitEmbedded(ctx WalkContext, iface *types.Interface, embedded types.Type) error {
+	top := sb.top()
+	if named, ok := embedded.(*types.Named); ok {
+		top.typ.Embeds = append(top.typ.Embeds, sb.ref(named))
+	}
+	return nil
+}
+
+func (sb *schemaBuilder) EnterImplementer(ctx WalkContext, iface types.Type, impl Implementer) error {
+	return nil
+}
+
+func (sb *schemaBuilder) LeaveImplementer(ctx WalkContext, iface types.Type, impl Implementer) error {
+	return nil
+}
@@ -0,0 +1,102 @@
+package structer
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestSchema(t *testing.T) {
+	pkg := "github.com/shabbyrobe/structer/testpkg/schema"
+	tpset := NewTypePackageSet()
+	if _, err := tpset.Import(pkg); err != nil {
+		t.Fatal(err)
+	}
+
+	schema, err := tpset.Schema(NewTypeName(pkg, "Drawing"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, decl := range schema.Types {
+		names = append(names, decl.Name)
+	}
+	expected := []string{pkg + ".Circle", pkg + ".Color", pkg + ".Drawing", pkg + ".Shape"}
+	if !reflect.DeepEqual(expected, names) {
+		t.Fatal(names)
+	}
+
+	drawing := schema.Lookup(pkg + ".Drawing")
+	if drawing.Doc != "Drawing is the root of the schema.\n" {
+		t.Fatalf("%q", drawing.Doc)
+	}
+	fields := drawing.Type.Fields
+	if len(fields) != 6 {
+		t.Fatal(fields)
+	}
+	if fields[0].Doc != "Title of the drawing.\n" || fields[0].Tag != `json:"title"` || fields[0].Type.Basic != "string" {
+		t.Fatal(fields[0])
+	}
+	if fields[1].Type.Kind != SchemaRef || fields[1].Type.Ref != pkg+".Color" {
+		t.Fatal(fields[1].Type)
+	}
+	if fields[2].Type.Kind != SchemaList || fields[2].Type.Elem.Ref != pkg+".Shape" {
+		t.Fatal(fields[2].Type)
+	}
+	if fields[3].Type.Kind != SchemaOptional || fields[3].Type.Elem.Ref != pkg+".Drawing" {
+		t.Fatal(fields[3].Type)
+	}
+	layers := fields[4].Type
+	if layers.Kind != SchemaMap || layers.Key.Basic != "string" || layers.Elem.Kind != SchemaArray ||
+		layers.Elem.Len != 2 || layers.Elem.Elem.Basic != "int" {
+		t.Fatal(layers)
+	}
+	if fields[5].Type.Ref != "time.Time" || schema.Lookup("time.Time") != nil {
+		t.Fatal(fields[5].Type)
+	}
+
+	color := schema.Lookup(pkg + ".Color").Type
+	if color.Kind != SchemaEnum || !color.Closed || color.Basic != "string" || len(color.Values) != 2 {
+		t.Fatal(color)
+	}
+	if *color.Values[0] != (SchemaEnumValue{Name: "Red", Value: `"red"`, Doc: "Red is red.\n"}) ||
+		*color.Values[1] != (SchemaEnumValue{Name: "Green", Value: `"green"`}) {
+		t.Fatal(color.Values[0], color.Values[1])
+	}
+
+	shape := schema.Lookup(pkg + ".Shape").Type
+	if len(shape.Methods) != 1 || *shape.Methods[0] != (SchemaMethod{Name: "Area", Doc: "Area returns the area of the shape.\n", Signature: "func() float64"}) {
+		t.Fatal(shape.Methods)
+	}
+	if !reflect.DeepEqual([]string{pkg + ".Circle"}, shape.Implementers) {
+		t.Fatal(shape.Implementers)
+	}
+}
+
+func TestSchemaJSON(t *testing.T) {
+	pkg := "github.com/shabbyrobe/structer/testpkg/schema"
+	tpset := NewTypePackageSet()
+	if _, err := tpset.Import(pkg); err != nil {
+		t.Fatal(err)
+	}
+	schema, err := tpset.Schema(NewTypeName(pkg, "Drawing"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bts, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Schema
+	if err := json.Unmarshal(bts, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(schema, &decoded) {
+		t.Fatal(string(bts))
+	}
+	if decoded.Version != SchemaVersion || decoded.Roots[0] != pkg+".Drawing" {
+		t.Fatal(decoded.Version, decoded.Roots)
+	}
+}
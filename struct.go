@@ -3,13 +3,32 @@ package structer
 import (
 	"errors"
 	"fmt"
+	"go/ast"
+	"go/token"
 	"go/types"
 )
 
+// Sentinel errors used to control a Walk. They may be returned from any of
+// the Enter methods of a TypeVisitor. If returned from a Leave or Visit method,
+// SkipChildren and SkipNode are ignored, except for VisitNamed, where they
+// prevent the implementers of a named interface from being visited.
 var (
-	// step over the current node. used in Enter calls to go to the
-	// next type without descending.
-	WalkOver = errors.New("stop")
+	// SkipChildren prevents Walk from descending into the children of the
+	// current node. The corresponding Leave method is still called.
+	SkipChildren = errors.New("skip children")
+
+	// SkipNode prevents Walk from descending into the children of the
+	// current node, and also skips the corresponding Leave method.
+	SkipNode = errors.New("skip node")
+
+	// StopWalk aborts the entire Walk. No further methods are called on the
+	// visitor, and Walk returns nil.
+	StopWalk = errors.New("stop walk")
+
+	// WalkOver is the same as SkipNode.
+	//
+	// Deprecated: use SkipNode or SkipChildren.
+	WalkOver = SkipNode
 )
 
 // interface check
@@ -31,17 +50,95 @@ var (
 // It is designed for use with code generators that want to dismantle a
 // struct and respond dynamically to the types therein.
 //
-func Walk(tn TypeName, t types.Type, visitor TypeVisitor) error {
-	ctx := &walkContext{visitor: visitor}
-	return ctx.walk(tn.PackagePath, tn.Name, tn, t)
+func Walk(tn TypeName, t types.Type, visitor TypeVisitor, opts ...WalkOption) error {
+	ctx := &walkContext{root: tn, visitor: visitor}
+	for _, o := range opts {
+		o(ctx)
+	}
+	if ctx.sizes == nil {
+		var sizes types.Sizes
+		var err error
+		if ctx.tpset != nil {
+			sizes, err = ctx.tpset.Sizes()
+		} else {
+			sizes, err = SizesFor(BuildContext.GOARCH)
+		}
+		if err != nil {
+			return err
+		}
+		ctx.sizes = sizes
+	}
+	err := ctx.walk(tn.PackagePath, tn.Name, tn, t)
+	if err == StopWalk {
+		return nil
+	}
+	return err
+}
+
+type WalkOption func(ctx *walkContext)
+
+// WalkPromoted causes Walk to replace embedded struct fields with the fields
+// promoted from them, using the rules described by EffectiveFields. Ambiguous
+// fields are not visited.
+func WalkPromoted() WalkOption {
+	return func(ctx *walkContext) {
+		ctx.promoted = true
+	}
+}
+
+// WalkDiagnostics supplies a handler for problems found during the Walk that
+// do not stop the Walk, like malformed struct tags.
+func WalkDiagnostics(h func(Diagnostic)) WalkOption {
+	return func(ctx *walkContext) {
+		ctx.diagnostics = h
+	}
+}
+
+// WalkTypePackageSet supplies the TypePackageSet that the walked type was
+// imported into. This allows Walk to pass extra information, like
+// documentation, to the visitor.
+func WalkTypePackageSet(tpset *TypePackageSet) WalkOption {
+	return func(ctx *walkContext) {
+		ctx.tpset = tpset
+	}
+}
+
+// WalkImplementers causes Walk to call EnterImplementer and LeaveImplementer
+// for each type that implements an interface it encounters, using the types
+// in the TypePackageSet passed with WalkTypePackageSet, as found by
+// ImplementersOf. Every type implements an empty interface.
+//
+// If descend is true, the underlying type of each implementer is walked
+// between EnterImplementer and LeaveImplementer, like a branch of a tagged
+// union. An implementer is not descended into if it is already being walked
+// further up the tree, or if it is the root of the Walk.
+//
+func WalkImplementers(descend bool) WalkOption {
+	return func(ctx *walkContext) {
+		ctx.implementers = true
+		ctx.descendImplementers = descend
+	}
+}
+
+// WalkSizes sets the types.Sizes used to calculate the StructInfo.Layout and
+// FieldInfo.Layout passed to the visitor. It defaults to the
+// TypePackageSet's Sizes if WalkTypePackageSet is passed, otherwise to the
+// sizes for BuildContext.GOARCH.
+func WalkSizes(sizes types.Sizes) WalkOption {
+	return func(ctx *walkContext) {
+		ctx.sizes = sizes
+	}
 }
 
 type TypeVisitor interface {
 	EnterStruct(WalkContext, StructInfo) error
 	LeaveStruct(WalkContext, StructInfo) error
 
-	EnterField(ctx WalkContext, s StructInfo, field *types.Var, tag string) error
-	LeaveField(ctx WalkContext, s StructInfo, field *types.Var, tag string) error
+	EnterField(ctx WalkContext, s StructInfo, field FieldInfo, tag StructTag) error
+	LeaveField(ctx WalkContext, s StructInfo, field FieldInfo, tag StructTag) error
+
+	EnterMap(ctx WalkContext, ft *types.Map) error
+	LeaveMap(ctx WalkContext, ft *types.Map) error
 
 	EnterMapKey(ctx WalkContext, ft *types.Map, key types.Type) error
 	LeaveMapKey(ctx WalkContext, ft *types.Map, key types.Type) error
@@ -58,10 +155,28 @@ type TypeVisitor interface {
 	EnterArray(ctx WalkContext, ft *types.Array) error
 	LeaveArray(ctx WalkContext, ft *types.Array) error
 
+	EnterInterface(ctx WalkContext, t *types.Interface) error
+	LeaveInterface(ctx WalkContext, t *types.Interface) error
+
+	// VisitMethod is called between EnterInterface and LeaveInterface for
+	// each method explicitly declared by the interface.
+	VisitMethod(ctx WalkContext, iface *types.Interface, method MethodInfo) error
+
+	// VisitEmbedded is called between EnterInterface and LeaveInterface for
+	// each interface embedded in the interface.
+	VisitEmbedded(ctx WalkContext, iface *types.Interface, embedded types.Type) error
+
+	// EnterImplementer and LeaveImplementer are called for each type that
+	// implements an interface if WalkImplementers is passed to Walk. For a
+	// named interface they are called after VisitNamed, for an interface
+	// literal they are called before LeaveInterface. iface is the
+	// *types.Named or *types.Interface.
+	EnterImplementer(ctx WalkContext, iface types.Type, impl Implementer) error
+	LeaveImplementer(ctx WalkContext, iface types.Type, impl Implementer) error
+
 	VisitBasic(ctx WalkContext, t *types.Basic) error
 	VisitNamed(ctx WalkContext, t *types.Named) error
 	VisitInvalid(ctx WalkContext, root TypeName, t *types.Basic) error
-	VisitInterface(ctx WalkContext, t *types.Interface) error
 }
 
 // PartialTypeVisitor allows you to conveniently construct a visitor using
@@ -72,8 +187,11 @@ type PartialTypeVisitor struct {
 	EnterStructFunc func(WalkContext, StructInfo) error
 	LeaveStructFunc func(WalkContext, StructInfo) error
 
-	EnterFieldFunc func(ctx WalkContext, s StructInfo, field *types.Var, tag string) error
-	LeaveFieldFunc func(ctx WalkContext, s StructInfo, field *types.Var, tag string) error
+	EnterFieldFunc func(ctx WalkContext, s StructInfo, field FieldInfo, tag StructTag) error
+	LeaveFieldFunc func(ctx WalkContext, s StructInfo, field FieldInfo, tag StructTag) error
+
+	EnterMapFunc func(ctx WalkContext, ft *types.Map) error
+	LeaveMapFunc func(ctx WalkContext, ft *types.Map) error
 
 	EnterMapKeyFunc func(ctx WalkContext, ft *types.Map, key types.Type) error
 	LeaveMapKeyFunc func(ctx WalkContext, ft *types.Map, key types.Type) error
@@ -90,10 +208,18 @@ type PartialTypeVisitor struct {
 	EnterArrayFunc func(ctx WalkContext, t *types.Array) error
 	LeaveArrayFunc func(ctx WalkContext, t *types.Array) error
 
-	VisitBasicFunc     func(ctx WalkContext, t *types.Basic) error
-	VisitNamedFunc     func(ctx WalkContext, t *types.Named) error
-	VisitInvalidFunc   func(ctx WalkContext, root TypeName, t *types.Basic) error
-	VisitInterfaceFunc func(ctx WalkContext, t *types.Interface) error
+	EnterInterfaceFunc func(ctx WalkContext, t *types.Interface) error
+	LeaveInterfaceFunc func(ctx WalkContext, t *types.Interface) error
+
+	VisitMethodFunc   func(ctx WalkContext, iface *types.Interface, method MethodInfo) error
+	VisitEmbeddedFunc func(ctx WalkContext, iface *types.Interface, embedded types.Type) error
+
+	EnterImplementerFunc func(ctx WalkContext, iface types.Type, impl Implementer) error
+	LeaveImplementerFunc func(ctx WalkContext, iface types.Type, impl Implementer) error
+
+	VisitBasicFunc   func(ctx WalkContext, t *types.Basic) error
+	VisitNamedFunc   func(ctx WalkContext, t *types.Named) error
+	VisitInvalidFunc func(ctx WalkContext, root TypeName, t *types.Basic) error
 }
 
 func (p *PartialTypeVisitor) EnterStruct(ctx WalkContext, s StructInfo) error {
@@ -110,20 +236,34 @@ func (p *PartialTypeVisitor) LeaveStruct(ctx WalkContext, s StructInfo) error {
 	return nil
 }
 
-func (p *PartialTypeVisitor) EnterField(ctx WalkContext, s StructInfo, field *types.Var, tag string) error {
+func (p *PartialTypeVisitor) EnterField(ctx WalkContext, s StructInfo, field FieldInfo, tag StructTag) error {
 	if p.EnterFieldFunc != nil {
 		return p.EnterFieldFunc(ctx, s, field, tag)
 	}
 	return nil
 }
 
-func (p *PartialTypeVisitor) LeaveField(ctx WalkContext, s StructInfo, field *types.Var, tag string) error {
+func (p *PartialTypeVisitor) LeaveField(ctx WalkContext, s StructInfo, field FieldInfo, tag StructTag) error {
 	if p.LeaveFieldFunc != nil {
 		return p.LeaveFieldFunc(ctx, s, field, tag)
 	}
 	return nil
 }
 
+func (p *PartialTypeVisitor) EnterMap(ctx WalkContext, ft *types.Map) error {
+	if p.EnterMapFunc != nil {
+		return p.EnterMapFunc(ctx, ft)
+	}
+	return nil
+}
+
+func (p *PartialTypeVisitor) LeaveMap(ctx WalkContext, ft *types.Map) error {
+	if p.LeaveMapFunc != nil {
+		return p.LeaveMapFunc(ctx, ft)
+	}
+	return nil
+}
+
 func (p *PartialTypeVisitor) EnterMapKey(ctx WalkContext, ft *types.Map, key types.Type) error {
 	if p.EnterMapKeyFunc != nil {
 		return p.EnterMapKeyFunc(ctx, ft, key)
@@ -154,14 +294,14 @@ func (p *PartialTypeVisitor) LeaveMapElem(ctx WalkContext, ft *types.Map, elem t
 
 func (p *PartialTypeVisitor) EnterPointer(ctx WalkContext, t *types.Pointer) error {
 	if p.EnterPointerFunc != nil {
-		return p.EnterPointer(ctx, t)
+		return p.EnterPointerFunc(ctx, t)
 	}
 	return nil
 }
 
 func (p *PartialTypeVisitor) LeavePointer(ctx WalkContext, t *types.Pointer) error {
 	if p.LeavePointerFunc != nil {
-		return p.LeavePointer(ctx, t)
+		return p.LeavePointerFunc(ctx, t)
 	}
 	return nil
 }
@@ -215,9 +355,44 @@ func (p *PartialTypeVisitor) VisitNamed(ctx WalkContext, t *types.Named) error {
 	return nil
 }
 
-func (p *PartialTypeVisitor) VisitInterface(ctx WalkContext, t *types.Interface) error {
-	if p.VisitInterfaceFunc != nil {
-		return p.VisitInterfaceFunc(ctx, t)
+func (p *PartialTypeVisitor) EnterInterface(ctx WalkContext, t *types.Interface) error {
+	if p.EnterInterfaceFunc != nil {
+		return p.EnterInterfaceFunc(ctx, t)
+	}
+	return nil
+}
+
+func (p *PartialTypeVisitor) LeaveInterface(ctx WalkContext, t *types.Interface) error {
+	if p.LeaveInterfaceFunc != nil {
+		return p.LeaveInterfaceFunc(ctx, t)
+	}
+	return nil
+}
+
+func (p *PartialTypeVisitor) VisitMethod(ctx WalkContext, iface *types.Interface, method MethodInfo) error {
+	if p.VisitMethodFunc != nil {
+		return p.VisitMethodFunc(ctx, iface, method)
+	}
+	return nil
+}
+
+func (p *PartialTypeVisitor) VisitEmbedded(ctx WalkContext, iface *types.Interface, embedded types.Type) error {
+	if p.VisitEmbeddedFunc != nil {
+		return p.VisitEmbeddedFunc(ctx, iface, embedded)
+	}
+	return nil
+}
+
+func (p *PartialTypeVisitor) EnterImplementer(ctx WalkContext, iface types.Type, impl Implementer) error {
+	if p.EnterImplementerFunc != nil {
+		return p.EnterImplementerFunc(ctx, iface, impl)
+	}
+	return nil
+}
+
+func (p *PartialTypeVisitor) LeaveImplementer(ctx WalkContext, iface types.Type, impl Implementer) error {
+	if p.LeaveImplementerFunc != nil {
+		return p.LeaveImplementerFunc(ctx, iface, impl)
 	}
 	return nil
 }
@@ -250,7 +425,7 @@ func (p *MultiVisitor) LeaveStruct(ctx WalkContext, s StructInfo) error {
 	return nil
 }
 
-func (p *MultiVisitor) EnterField(ctx WalkContext, s StructInfo, field *types.Var, tag string) error {
+func (p *MultiVisitor) EnterField(ctx WalkContext, s StructInfo, field FieldInfo, tag StructTag) error {
 	for _, v := range p.Visitors {
 		if err := v.EnterField(ctx, s, field, tag); err != nil {
 			return err
@@ -259,7 +434,7 @@ func (p *MultiVisitor) EnterField(ctx WalkContext, s StructInfo, field *types.Va
 	return nil
 }
 
-func (p *MultiVisitor) LeaveField(ctx WalkContext, s StructInfo, field *types.Var, tag string) error {
+func (p *MultiVisitor) LeaveField(ctx WalkContext, s StructInfo, field FieldInfo, tag StructTag) error {
 	for _, v := range p.Visitors {
 		if err := v.LeaveField(ctx, s, field, tag); err != nil {
 			return err
@@ -268,6 +443,24 @@ func (p *MultiVisitor) LeaveField(ctx WalkContext, s StructInfo, field *types.Va
 	return nil
 }
 
+func (p *MultiVisitor) EnterMap(ctx WalkContext, ft *types.Map) error {
+	for _, v := range p.Visitors {
+		if err := v.EnterMap(ctx, ft); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *MultiVisitor) LeaveMap(ctx WalkContext, ft *types.Map) error {
+	for _, v := range p.Visitors {
+		if err := v.LeaveMap(ctx, ft); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (p *MultiVisitor) EnterMapKey(ctx WalkContext, ft *types.Map, key types.Type) error {
 	for _, v := range p.Visitors {
 		if err := v.EnterMapKey(ctx, ft, key); err != nil {
@@ -385,9 +578,54 @@ func (p *MultiVisitor) VisitNamed(ctx WalkContext, t *types.Named) error {
 	return nil
 }
 
-func (p *MultiVisitor) VisitInterface(ctx WalkContext, t *types.Interface) error {
+func (p *MultiVisitor) EnterInterface(ctx WalkContext, t *types.Interface) error {
+	for _, v := range p.Visitors {
+		if err := v.EnterInterface(ctx, t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *MultiVisitor) LeaveInterface(ctx WalkContext, t *types.Interface) error {
+	for _, v := range p.Visitors {
+		if err := v.LeaveInterface(ctx, t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *MultiVisitor) VisitMethod(ctx WalkContext, iface *types.Interface, method MethodInfo) error {
+	for _, v := range p.Visitors {
+		if err := v.VisitMethod(ctx, iface, method); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *MultiVisitor) VisitEmbedded(ctx WalkContext, iface *types.Interface, embedded types.Type) error {
+	for _, v := range p.Visitors {
+		if err := v.VisitEmbedded(ctx, iface, embedded); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *MultiVisitor) EnterImplementer(ctx WalkContext, iface types.Type, impl Implementer) error {
 	for _, v := range p.Visitors {
-		if err := v.VisitInterface(ctx, t); err != nil {
+		if err := v.EnterImplementer(ctx, iface, impl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *MultiVisitor) LeaveImplementer(ctx WalkContext, iface types.Type, impl Implementer) error {
+	for _, v := range p.Visitors {
+		if err := v.LeaveImplementer(ctx, iface, impl); err != nil {
 			return err
 		}
 	}
@@ -397,11 +635,71 @@ func (p *MultiVisitor) VisitInterface(ctx WalkContext, t *types.Interface) error
 type WalkContext interface {
 	Stack() []types.Type
 	Parent() types.Type
+
+	// Path returns the steps taken from the root of the Walk to reach the
+	// current node. Use WalkPath.Expr to render it as a Go expression.
+	Path() WalkPath
 }
 
 type walkContext struct {
-	stack   []types.Type
-	visitor TypeVisitor
+	root        TypeName
+	stack       []types.Type
+	path        WalkPath
+	visitor     TypeVisitor
+	sizes       types.Sizes
+	tpset       *TypePackageSet
+	promoted    bool
+	diagnostics func(Diagnostic)
+
+	implementers        bool
+	descendImplementers bool
+	implCache           map[types.Type][]Implementer
+	implActive          map[types.Type]bool
+}
+
+func (ctx *walkContext) diagnose(diag Diagnostic) {
+	if ctx.diagnostics == nil {
+		return
+	}
+	diag.Position = ctx.position(diag.Pos)
+	ctx.diagnostics(diag)
+}
+
+// position returns the source position for pos, if it is known.
+func (ctx *walkContext) position(pos token.Pos) token.Position {
+	if ctx.tpset == nil || !pos.IsValid() {
+		return token.Position{}
+	}
+	return ctx.tpset.ASTPackages.FileSet.Position(pos)
+}
+
+// structAST finds the declaration of st using the position of its first
+// field. Empty structs have no fields, so they can only be found if they are
+// the root of the Walk.
+func (ctx *walkContext) structAST(st *types.Struct) *ast.StructType {
+	if ctx.tpset == nil {
+		return nil
+	}
+	asts := ctx.tpset.ASTPackages
+	if st.NumFields() > 0 {
+		if field := asts.FindField(st.Field(0).Pos()); field != nil {
+			return asts.FindStructType(field)
+		}
+		return nil
+	}
+	if len(ctx.path) > 0 {
+		return nil
+	}
+	obj := ctx.tpset.FindObject(ctx.root)
+	if obj == nil || obj.Pkg() == nil {
+		return nil
+	}
+	if ts, ok := asts.FindNodeByPackagePathPos(obj.Pkg().Path(), obj.Pos()).(*ast.TypeSpec); ok {
+		if sast, ok := ts.Type.(*ast.StructType); ok {
+			return sast
+		}
+	}
+	return nil
 }
 
 func (ctx *walkContext) push(t types.Type) {
@@ -417,6 +715,20 @@ func (ctx *walkContext) pop(t types.Type) {
 	}
 }
 
+func (ctx *walkContext) pushPath(elem PathElem) {
+	ctx.path = append(ctx.path, elem)
+}
+
+func (ctx *walkContext) popPath() {
+	ctx.path = ctx.path[:len(ctx.path)-1]
+}
+
+func (ctx *walkContext) Path() WalkPath {
+	path := make(WalkPath, len(ctx.path))
+	copy(path, ctx.path)
+	return path
+}
+
 func (ctx *walkContext) Parent() types.Type {
 	ln := len(ctx.stack)
 	if ln > 0 {
@@ -429,6 +741,52 @@ func (ctx *walkContext) Stack() []types.Type {
 	return ctx.stack
 }
 
+// enter interprets the error returned by an Enter method. descend reports
+// whether the children of the node should be walked and leave reports whether
+// the corresponding Leave method should be called.
+func (ctx *walkContext) enter(err error) (descend, leave bool, rerr error) {
+	switch err {
+	case nil:
+		return true, true, nil
+	case SkipChildren:
+		return false, true, nil
+	case SkipNode:
+		return false, false, nil
+	default:
+		return false, false, ctx.wrap(err)
+	}
+}
+
+// result interprets the error returned by a Leave or Visit method. The
+// Skip sentinels are meaningless here, so they are ignored.
+func (ctx *walkContext) result(err error) error {
+	if err == SkipChildren || err == SkipNode {
+		return nil
+	}
+	return ctx.wrap(err)
+}
+
+// wrap annotates an error returned by the visitor with the location of the
+// current node. StopWalk and errors that have already been wrapped, i.e. by
+// a nested Walk, are returned unchanged.
+func (ctx *walkContext) wrap(err error) error {
+	if err == nil || err == StopWalk {
+		return err
+	}
+	if _, ok := err.(*WalkError); ok {
+		return err
+	}
+	werr := &WalkError{Root: ctx.root, Path: ctx.Path(), Err: err}
+	for i := len(werr.Path) - 1; i >= 0; i-- {
+		if werr.Path[i].Kind == PathField {
+			werr.Pos = werr.Path[i].Field.Pos()
+			break
+		}
+	}
+	werr.Position = ctx.position(werr.Pos)
+	return werr
+}
+
 func (ctx *walkContext) walk(pkg, name string, root TypeName, ft types.Type) error {
 	switch ft := ft.(type) {
 	case *types.Struct:
@@ -448,16 +806,25 @@ func (ctx *walkContext) walk(pkg, name string, root TypeName, ft types.Type) err
 		return ctx.walkPointer(pkg, name, root, ft)
 
 	case *types.Named:
-		return ctx.visitor.VisitNamed(ctx, ft)
+		err := ctx.visitor.VisitNamed(ctx, ft)
+		if err == SkipChildren || err == SkipNode {
+			return nil
+		} else if err != nil {
+			return ctx.wrap(err)
+		}
+		if ctx.implementers && types.IsInterface(ft) {
+			return ctx.walkImplementers(root, ft)
+		}
+		return nil
 
 	case *types.Interface:
-		return ctx.visitor.VisitInterface(ctx, ft)
+		return ctx.walkInterface(pkg, name, root, ft)
 
 	case *types.Basic:
 		if ft.Kind() == types.Invalid {
-			return ctx.visitor.VisitInvalid(ctx, root, ft)
+			return ctx.result(ctx.visitor.VisitInvalid(ctx, root, ft))
 		} else {
-			return ctx.visitor.VisitBasic(ctx, ft)
+			return ctx.result(ctx.visitor.VisitBasic(ctx, ft))
 		}
 
 	default:
@@ -465,21 +832,29 @@ func (ctx *walkContext) walk(pkg, name string, root TypeName, ft types.Type) err
 	}
 }
 
+// walkElem walks the child of a slice, array or pointer with the path
+// extended by elem.
+func (ctx *walkContext) walkElem(pkg string, root TypeName, elem PathElem, t types.Type) error {
+	ctx.pushPath(elem)
+	defer ctx.popPath()
+	return ctx.walk(pkg, t.String(), root, t)
+}
+
 func (ctx *walkContext) walkSlice(pkg, name string, root TypeName, ft *types.Slice) error {
 	ctx.push(ft)
 	defer ctx.pop(ft)
 
-	err := ctx.visitor.EnterSlice(ctx, ft)
-	if err == WalkOver {
-		return nil
-	} else if err != nil {
+	descend, leave, err := ctx.enter(ctx.visitor.EnterSlice(ctx, ft))
+	if err != nil {
 		return err
 	}
-	if err := ctx.walk(pkg, ft.Elem().String(), root, ft.Elem()); err != nil {
-		return err
+	if descend {
+		if err := ctx.walkElem(pkg, root, PathElem{Kind: PathSliceElem, Type: ft}, ft.Elem()); err != nil {
+			return err
+		}
 	}
-	if err := ctx.visitor.LeaveSlice(ctx, ft); err != nil {
-		return err
+	if leave {
+		return ctx.result(ctx.visitor.LeaveSlice(ctx, ft))
 	}
 	return nil
 }
@@ -488,17 +863,17 @@ func (ctx *walkContext) walkPointer(pkg, name string, root TypeName, ft *types.P
 	ctx.push(ft)
 	defer ctx.pop(ft)
 
-	err := ctx.visitor.EnterPointer(ctx, ft)
-	if err == WalkOver {
-		return nil
-	} else if err != nil {
+	descend, leave, err := ctx.enter(ctx.visitor.EnterPointer(ctx, ft))
+	if err != nil {
 		return err
 	}
-	if err := ctx.walk(pkg, ft.Elem().String(), root, ft.Elem()); err != nil {
-		return err
+	if descend {
+		if err := ctx.walkElem(pkg, root, PathElem{Kind: PathDeref, Type: ft}, ft.Elem()); err != nil {
+			return err
+		}
 	}
-	if err := ctx.visitor.LeavePointer(ctx, ft); err != nil {
-		return err
+	if leave {
+		return ctx.result(ctx.visitor.LeavePointer(ctx, ft))
 	}
 	return nil
 }
@@ -507,17 +882,17 @@ func (ctx *walkContext) walkArray(pkg, name string, root TypeName, ft *types.Arr
 	ctx.push(ft)
 	defer ctx.pop(ft)
 
-	err := ctx.visitor.EnterArray(ctx, ft)
-	if err == WalkOver {
-		return nil
-	} else if err != nil {
+	descend, leave, err := ctx.enter(ctx.visitor.EnterArray(ctx, ft))
+	if err != nil {
 		return err
 	}
-	if err := ctx.walk(pkg, ft.Elem().String(), root, ft.Elem()); err != nil {
-		return err
+	if descend {
+		if err := ctx.walkElem(pkg, root, PathElem{Kind: PathArrayElem, Type: ft}, ft.Elem()); err != nil {
+			return err
+		}
 	}
-	if err := ctx.visitor.LeaveArray(ctx, ft); err != nil {
-		return err
+	if leave {
+		return ctx.result(ctx.visitor.LeaveArray(ctx, ft))
 	}
 	return nil
 }
@@ -526,70 +901,280 @@ func (ctx *walkContext) walkMap(pkg, name string, root TypeName, ft *types.Map)
 	ctx.push(ft)
 	defer ctx.pop(ft)
 
-	var err error
+	descend, leave, err := ctx.enter(ctx.visitor.EnterMap(ctx, ft))
+	if err != nil {
+		return err
+	}
+	if descend {
+		if err := ctx.walkMapKey(pkg, name, root, ft); err != nil {
+			return err
+		}
+		if err := ctx.walkMapElem(pkg, name, root, ft); err != nil {
+			return err
+		}
+	}
+	if leave {
+		return ctx.result(ctx.visitor.LeaveMap(ctx, ft))
+	}
+	return nil
+}
+
+func (ctx *walkContext) walkMapKey(pkg, name string, root TypeName, ft *types.Map) error {
+	ctx.pushPath(PathElem{Kind: PathMapKey, Type: ft})
+	defer ctx.popPath()
 
-	err = ctx.visitor.EnterMapKey(ctx, ft, ft.Key())
-	if err != WalkOver && err != nil {
+	descend, leave, err := ctx.enter(ctx.visitor.EnterMapKey(ctx, ft, ft.Key()))
+	if err != nil {
 		return err
 	}
-	if err != WalkOver {
+	if descend {
 		if err := ctx.walk(pkg, ft.Key().String(), root, ft.Key()); err != nil {
 			return err
 		}
-		if err := ctx.visitor.LeaveMapKey(ctx, ft, ft.Key()); err != nil {
+	}
+	if leave {
+		return ctx.result(ctx.visitor.LeaveMapKey(ctx, ft, ft.Key()))
+	}
+	return nil
+}
+
+func (ctx *walkContext) walkMapElem(pkg, name string, root TypeName, ft *types.Map) error {
+	ctx.pushPath(PathElem{Kind: PathMapElem, Type: ft})
+	defer ctx.popPath()
+
+	descend, leave, err := ctx.enter(ctx.visitor.EnterMapElem(ctx, ft, ft.Elem()))
+	if err != nil {
+		return err
+	}
+	if descend {
+		if err := ctx.walk(pkg, ft.Elem().String(), root, ft.Elem()); err != nil {
 			return err
 		}
 	}
+	if leave {
+		return ctx.result(ctx.visitor.LeaveMapElem(ctx, ft, ft.Elem()))
+	}
+	return nil
+}
+
+func (ctx *walkContext) walkInterface(pkg, name string, root TypeName, ft *types.Interface) error {
+	ctx.push(ft)
+	defer ctx.pop(ft)
 
-	err = ctx.visitor.EnterMapElem(ctx, ft, ft.Elem())
-	if err != WalkOver && err != nil {
+	descend, leave, err := ctx.enter(ctx.visitor.EnterInterface(ctx, ft))
+	if err != nil {
 		return err
 	}
-	if err != WalkOver {
-		if err := ctx.walk(pkg, ft.Elem().String(), root, ft.Elem()); err != nil {
+	if descend {
+		for i := 0; i < ft.NumEmbeddeds(); i++ {
+			if err := ctx.result(ctx.visitor.VisitEmbedded(ctx, ft, ft.EmbeddedType(i))); err != nil {
+				return err
+			}
+		}
+		for i := 0; i < ft.NumExplicitMethods(); i++ {
+			method, err := ctx.methodInfo(ft.ExplicitMethod(i))
+			if err != nil {
+				return err
+			}
+			if err := ctx.result(ctx.visitor.VisitMethod(ctx, ft, method)); err != nil {
+				return err
+			}
+		}
+		if ctx.implementers {
+			if err := ctx.walkImplementers(root, ft); err != nil {
+				return err
+			}
+		}
+	}
+	if leave {
+		return ctx.result(ctx.visitor.LeaveInterface(ctx, ft))
+	}
+	return nil
+}
+
+// walkImplementers visits each implementer of iface, which is either a
+// *types.Named or a *types.Interface.
+func (ctx *walkContext) walkImplementers(root TypeName, iface types.Type) error {
+	if ctx.tpset == nil {
+		return fmt.Errorf("structer: WalkImplementers requires WalkTypePackageSet")
+	}
+	impls, ok := ctx.implCache[iface]
+	if !ok {
+		impls = ctx.tpset.ImplementersOf(iface)
+		if ctx.implCache == nil {
+			ctx.implCache = map[types.Type][]Implementer{}
+		}
+		ctx.implCache[iface] = impls
+	}
+	for _, impl := range impls {
+		if err := ctx.walkImplementer(root, iface, impl); err != nil {
 			return err
 		}
-		if err := ctx.visitor.LeaveMapElem(ctx, ft, ft.Elem()); err != nil {
+	}
+	return nil
+}
+
+func (ctx *walkContext) walkImplementer(root TypeName, iface types.Type, impl Implementer) error {
+	ctx.pushPath(PathElem{Kind: PathTypeAssert, Type: impl.Type})
+	defer ctx.popPath()
+
+	descend, leave, err := ctx.enter(ctx.visitor.EnterImplementer(ctx, iface, impl))
+	if err != nil {
+		return err
+	}
+
+	named := namedOf(impl.Type)
+	if descend && ctx.descendImplementers && named != nil && !ctx.implActive[named] && impl.Name != ctx.root {
+		if ctx.implActive == nil {
+			ctx.implActive = map[types.Type]bool{}
+		}
+		ctx.implActive[named] = true
+		if impl.Pointer {
+			ctx.pushPath(PathElem{Kind: PathDeref, Type: impl.Type})
+		}
+		err := ctx.walk(named.Obj().Pkg().Name(), named.Obj().Name(), root, named.Underlying())
+		if impl.Pointer {
+			ctx.popPath()
+		}
+		delete(ctx.implActive, named)
+		if err != nil {
 			return err
 		}
 	}
+
+	if leave {
+		return ctx.result(ctx.visitor.LeaveImplementer(ctx, iface, impl))
+	}
 	return nil
 }
 
+func (ctx *walkContext) methodInfo(fn *types.Func) (info MethodInfo, err error) {
+	info = MethodInfo{
+		Func:      fn,
+		Name:      fn.Name(),
+		Signature: fn.Type().(*types.Signature),
+	}
+	if ctx.tpset != nil && fn.Pkg() != nil {
+		info.Doc, err = ctx.tpset.ASTPackages.FindComment(fn.Pkg().Path(), fn.Pos())
+	}
+	return info, err
+}
+
 func (ctx *walkContext) walkStruct(pkg, name string, root TypeName, ft *types.Struct) error {
 	ctx.push(ft)
 	defer ctx.pop(ft)
 
-	sinfo := StructInfo{Package: pkg, Name: name, Root: root, Struct: ft}
+	sinfo := StructInfo{
+		Package: pkg,
+		Name:    name,
+		Root:    root,
+		Struct:  ft,
+		Layout:  NewStructLayout(ft, ctx.sizes),
+	}
+	sinfo.AST = ctx.structAST(ft)
+	if sinfo.AST != nil {
+		sinfo.Position = ctx.position(sinfo.AST.Pos())
+	}
 
-	err := ctx.visitor.EnterStruct(ctx, sinfo)
-	if err == WalkOver {
-		return nil
-	} else if err != nil {
+	if ctx.diagnostics != nil {
+		for _, diag := range structTagDiagnostics(ft) {
+			ctx.diagnose(diag)
+		}
+	}
+
+	descend, leave, err := ctx.enter(ctx.visitor.EnterStruct(ctx, sinfo))
+	if err != nil {
 		return err
 	}
+	if descend && ctx.promoted {
+		for _, ef := range EffectiveFields(ft, FieldSetOptions{}).Fields {
+			if err := ctx.walkPromotedField(sinfo, ef); err != nil {
+				return err
+			}
+		}
+	} else if descend {
+		for i := 0; i < ft.NumFields(); i++ {
+			if err := ctx.walkField(sinfo, i); err != nil {
+				return err
+			}
+		}
+	}
+	if leave {
+		return ctx.result(ctx.visitor.LeaveStruct(ctx, sinfo))
+	}
+	return nil
+}
 
-	for i := 0; i < ft.NumFields(); i++ {
-		field := ft.Field(i)
-		tag := ft.Tag(i)
+func (ctx *walkContext) walkField(sinfo StructInfo, idx int) error {
+	field := FieldInfo{
+		Var:    sinfo.Struct.Field(idx),
+		Index:  idx,
+		Layout: sinfo.Layout.Fields[idx],
+	}
+	path := []PathElem{{Kind: PathField, Type: sinfo.Struct, Field: field.Var, Index: idx}}
+	return ctx.walkFieldInfo(sinfo, field, sinfo.Struct.Tag(idx), path)
+}
 
-		err = ctx.visitor.EnterField(ctx, sinfo, field, tag)
-		if err == WalkOver {
-			err = nil
-			continue
-		} else if err != nil {
-			return err
+// walkPromotedField walks a field that has been promoted from an embedded
+// struct, i.e. if WalkPromoted is used.
+func (ctx *walkContext) walkPromotedField(sinfo StructInfo, ef EffectiveField) error {
+	var path []PathElem
+	container := sinfo.Struct
+	for i, emb := range ef.Embedding {
+		path = append(path, PathElem{Kind: PathField, Type: container, Field: emb, Index: ef.Index[i]})
+		if ptr, ok := emb.Type().(*types.Pointer); ok {
+			path = append(path, PathElem{Kind: PathDeref, Type: ptr})
 		}
+		container = embeddedStruct(emb)
+	}
 
-		if err := ctx.walk(field.Pkg().Name(), field.Name(), root, field.Type()); err != nil {
-			return err
+	idx := ef.Index[len(ef.Index)-1]
+	layout := sinfo.Layout
+	if container != sinfo.Struct {
+		layout = NewStructLayout(container, ctx.sizes)
+	}
+	field := FieldInfo{
+		Var:       ef.Field,
+		Index:     idx,
+		Layout:    layout.Fields[idx],
+		Embedding: ef.Embedding,
+	}
+	path = append(path, PathElem{Kind: PathField, Type: container, Field: ef.Field, Index: idx})
+	return ctx.walkFieldInfo(sinfo, field, ef.Tag, path)
+}
+
+func (ctx *walkContext) walkFieldInfo(sinfo StructInfo, field FieldInfo, rawTag string, path []PathElem) error {
+	tag := ParseStructTag(rawTag)
+	if ctx.tpset != nil {
+		field.AST = ctx.tpset.ASTPackages.FindField(field.Pos())
+		field.Position = ctx.position(field.Pos())
+	}
+	if field.AST != nil {
+		field.Doc = field.AST.Doc.Text()
+		field.Comment = field.AST.Comment.Text()
+		field.Directives = ParseDirectives(field.AST.Doc, field.AST.Comment)
+	}
+
+	for _, elem := range path {
+		ctx.pushPath(elem)
+		defer ctx.popPath()
+	}
+
+	descend, leave, err := ctx.enter(ctx.visitor.EnterField(ctx, sinfo, field, tag))
+	if err != nil {
+		return err
+	}
+	if descend {
+		var pkg string
+		if field.Pkg() != nil {
+			pkg = field.Pkg().Name()
 		}
-		if err := ctx.visitor.LeaveField(ctx, sinfo, field, tag); err != nil {
+		if err := ctx.walk(pkg, field.Name(), sinfo.Root, field.Type()); err != nil {
 			return err
 		}
 	}
-	if err := ctx.visitor.LeaveStruct(ctx, sinfo); err != nil {
-		return err
+	if leave {
+		return ctx.result(ctx.visitor.LeaveField(ctx, sinfo, field, tag))
 	}
 	return nil
 }
@@ -605,4 +1190,67 @@ type StructInfo struct {
 	Root TypeName
 
 	Struct *types.Struct
+
+	// Memory layout of the struct, calculated using the types.Sizes passed
+	// to Walk.
+	Layout *StructLayout
+
+	// AST and Position locate the struct's declaration in the source. They
+	// are only populated if a TypePackageSet is passed to Walk using
+	// WalkTypePackageSet. The AST for an empty struct can only be found if
+	// it is the root of the Walk.
+	AST      *ast.StructType
+	Position token.Position
+}
+
+// MethodInfo describes an interface method visited by Walk.
+type MethodInfo struct {
+	Func      *types.Func
+	Name      string
+	Signature *types.Signature
+
+	// Doc contains the documentation for the method. It is only populated
+	// if a TypePackageSet is passed to Walk using WalkTypePackageSet.
+	Doc string
+}
+
+// FieldInfo describes a struct field visited by Walk. The field's *types.Var
+// is embedded so FieldInfo can be used in much the same way.
+type FieldInfo struct {
+	*types.Var
+
+	// Index of the field in the containing struct.
+	Index int
+
+	// Memory layout of the field within the struct that declares it.
+	Layout FieldLayout
+
+	// Embedding contains the embedded fields traversed to reach a field
+	// promoted from an embedded struct, outermost first. It is only populated
+	// when the WalkPromoted option is used.
+	Embedding []*types.Var
+
+	// AST and Position locate the field's declaration in the source. They
+	// are only populated if a TypePackageSet is passed to Walk using
+	// WalkTypePackageSet. If several fields are declared together, i.e.
+	// "A, B int", they share the same AST.
+	AST      *ast.Field
+	Position token.Position
+
+	// Doc is the field's doc comment and Comment is the comment following
+	// it on the same line. Directives are collected from both. Like AST,
+	// they are only populated if a TypePackageSet is passed to Walk.
+	Doc        string
+	Comment    string
+	Directives Directives
+}
+
+// TypeExpr returns the field's type as it was written in the source, or nil
+// if the AST is not available. Use ASTPackageSet.NodeSource to retrieve the
+// original spelling.
+func (f FieldInfo) TypeExpr() ast.Expr {
+	if f.AST == nil {
+		return nil
+	}
+	return f.AST.Type
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"go/types"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -73,6 +74,11 @@ type TestingStruct struct {
 	BasicArray   [2]int
 	Interface    interface{}
 
+	InterfaceWithMethods interface {
+		fmt.Stringer
+		Foo(x int) error
+	}
+
 	BasicMap                     map[string]string
 	BasicMapOfBasicMap           map[string]map[string]string
 	BasicMapOfBasicMapOfBasicMap map[string]map[string]map[string]string
@@ -121,49 +127,69 @@ var fieldResults = map[string][]TestingVisitorEvent{
 	},
 
 	"BasicMap": []TestingVisitorEvent{
+		{Depth: 2, Kind: "EnterMap", Name: "map[string]string"},
 		{Depth: 2, Kind: "EnterMapKey", Name: "map[string]string"},
 		{Depth: 3, Kind: "VisitBasic", Name: "string"},
 		{Depth: 2, Kind: "LeaveMapKey", Name: "map[string]string"},
 		{Depth: 2, Kind: "EnterMapElem", Name: "map[string]string"},
 		{Depth: 3, Kind: "VisitBasic", Name: "string"},
 		{Depth: 2, Kind: "LeaveMapElem", Name: "map[string]string"},
+		{Depth: 2, Kind: "LeaveMap", Name: "map[string]string"},
 	},
 
 	"BasicMapOfBasicMap": []TestingVisitorEvent{
+		{Depth: 2, Kind: "EnterMap", Name: "map[string]map[string]string"},
 		{Depth: 2, Kind: "EnterMapKey", Name: "map[string]map[string]string"},
 		{Depth: 3, Kind: "VisitBasic", Name: "string"},
 		{Depth: 2, Kind: "LeaveMapKey", Name: "map[string]map[string]string"},
 		{Depth: 2, Kind: "EnterMapElem", Name: "map[string]map[string]string"},
+		{Depth: 3, Kind: "EnterMap", Name: "map[string]string"},
 		{Depth: 3, Kind: "EnterMapKey", Name: "map[string]string"},
 		{Depth: 4, Kind: "VisitBasic", Name: "string"},
 		{Depth: 3, Kind: "LeaveMapKey", Name: "map[string]string"},
 		{Depth: 3, Kind: "EnterMapElem", Name: "map[string]string"},
 		{Depth: 4, Kind: "VisitBasic", Name: "string"},
 		{Depth: 3, Kind: "LeaveMapElem", Name: "map[string]string"},
+		{Depth: 3, Kind: "LeaveMap", Name: "map[string]string"},
 		{Depth: 2, Kind: "LeaveMapElem", Name: "map[string]map[string]string"},
+		{Depth: 2, Kind: "LeaveMap", Name: "map[string]map[string]string"},
 	},
 
 	"BasicMapOfBasicMapOfBasicMap": []TestingVisitorEvent{
+		{Depth: 2, Kind: "EnterMap", Name: "map[string]map[string]map[string]string"},
 		{Depth: 2, Kind: "EnterMapKey", Name: "map[string]map[string]map[string]string"},
 		{Depth: 3, Kind: "VisitBasic", Name: "string"},
 		{Depth: 2, Kind: "LeaveMapKey", Name: "map[string]map[string]map[string]string"},
 		{Depth: 2, Kind: "EnterMapElem", Name: "map[string]map[string]map[string]string"},
+		{Depth: 3, Kind: "EnterMap", Name: "map[string]map[string]string"},
 		{Depth: 3, Kind: "EnterMapKey", Name: "map[string]map[string]string"},
 		{Depth: 4, Kind: "VisitBasic", Name: "string"},
 		{Depth: 3, Kind: "LeaveMapKey", Name: "map[string]map[string]string"},
 		{Depth: 3, Kind: "EnterMapElem", Name: "map[string]map[string]string"},
+		{Depth: 4, Kind: "EnterMap", Name: "map[string]string"},
 		{Depth: 4, Kind: "EnterMapKey", Name: "map[string]string"},
 		{Depth: 5, Kind: "VisitBasic", Name: "string"},
 		{Depth: 4, Kind: "LeaveMapKey", Name: "map[string]string"},
 		{Depth: 4, Kind: "EnterMapElem", Name: "map[string]string"},
 		{Depth: 5, Kind: "VisitBasic", Name: "string"},
 		{Depth: 4, Kind: "LeaveMapElem", Name: "map[string]string"},
+		{Depth: 4, Kind: "LeaveMap", Name: "map[string]string"},
 		{Depth: 3, Kind: "LeaveMapElem", Name: "map[string]map[string]string"},
+		{Depth: 3, Kind: "LeaveMap", Name: "map[string]map[string]string"},
 		{Depth: 2, Kind: "LeaveMapElem", Name: "map[string]map[string]map[string]string"},
+		{Depth: 2, Kind: "LeaveMap", Name: "map[string]map[string]map[string]string"},
 	},
 
 	"Interface": []TestingVisitorEvent{
-		{Depth: 2, Kind: "VisitInterface", Name: "interface{}"},
+		{Depth: 2, Kind: "EnterInterface", Name: "interface{}"},
+		{Depth: 2, Kind: "LeaveInterface", Name: "interface{}"},
+	},
+
+	"InterfaceWithMethods": []TestingVisitorEvent{
+		{Depth: 2, Kind: "EnterInterface", Name: "interface{Foo(x int) error; fmt.Stringer}"},
+		{Depth: 3, Kind: "VisitEmbedded", Name: "fmt.Stringer"},
+		{Depth: 3, Kind: "VisitMethod", Name: "Foo"},
+		{Depth: 2, Kind: "LeaveInterface", Name: "interface{Foo(x int) error; fmt.Stringer}"},
 	},
 
 	"SliceOfSlice": []TestingVisitorEvent{
@@ -231,6 +257,7 @@ var fieldResults = map[string][]TestingVisitorEvent{
 	},
 
 	"MapWithArrayKey": []TestingVisitorEvent{
+		{Depth: 2, Kind: "EnterMap", Name: "map[[2]int]string"},
 		{Depth: 2, Kind: "EnterMapKey", Name: "map[[2]int]string"},
 		{Depth: 3, Kind: "EnterArray", Name: "[2]int"},
 		{Depth: 4, Kind: "VisitBasic", Name: "int"},
@@ -239,9 +266,11 @@ var fieldResults = map[string][]TestingVisitorEvent{
 		{Depth: 2, Kind: "EnterMapElem", Name: "map[[2]int]string"},
 		{Depth: 3, Kind: "VisitBasic", Name: "string"},
 		{Depth: 2, Kind: "LeaveMapElem", Name: "map[[2]int]string"},
+		{Depth: 2, Kind: "LeaveMap", Name: "map[[2]int]string"},
 	},
 
 	"MapWithStructKey": []TestingVisitorEvent{ //
+		{Depth: 2, Kind: "EnterMap", Name: "map[struct{X int; Y int}]string"},
 		{Depth: 2, Kind: "EnterMapKey", Name: "map[struct{X int; Y int}]string"},
 		{Depth: 3, Kind: "EnterStruct", Name: "struct{X int; Y int}"},
 		{Depth: 4, Kind: "EnterField", Name: "X"},
@@ -255,9 +284,11 @@ var fieldResults = map[string][]TestingVisitorEvent{
 		{Depth: 2, Kind: "EnterMapElem", Name: "map[struct{X int; Y int}]string"},
 		{Depth: 3, Kind: "VisitBasic", Name: "string"},
 		{Depth: 2, Kind: "LeaveMapElem", Name: "map[struct{X int; Y int}]string"},
+		{Depth: 2, Kind: "LeaveMap", Name: "map[struct{X int; Y int}]string"},
 	},
 
 	"MapWithNestedStructKey": []TestingVisitorEvent{ //map[struct{ Foo struct{ Bar string } }]string
+		{Depth: 2, Kind: "EnterMap", Name: "map[struct{Foo struct{Bar string}}]string"},
 		{Depth: 2, Kind: "EnterMapKey", Name: "map[struct{Foo struct{Bar string}}]string"},
 		{Depth: 3, Kind: "EnterStruct", Name: "struct{Foo struct{Bar string}}"},
 		{Depth: 4, Kind: "EnterField", Name: "Foo"},
@@ -272,6 +303,7 @@ var fieldResults = map[string][]TestingVisitorEvent{
 		{Depth: 2, Kind: "EnterMapElem", Name: "map[struct{Foo struct{Bar string}}]string"},
 		{Depth: 3, Kind: "VisitBasic", Name: "string"},
 		{Depth: 2, Kind: "LeaveMapElem", Name: "map[struct{Foo struct{Bar string}}]string"},
+		{Depth: 2, Kind: "LeaveMap", Name: "map[struct{Foo struct{Bar string}}]string"},
 	},
 }
 
@@ -352,14 +384,14 @@ func (tv *TestingVisitor) LeaveStruct(ctx WalkContext, s StructInfo) error {
 	return nil
 }
 
-func (tv *TestingVisitor) EnterField(ctx WalkContext, s StructInfo, field *types.Var, tag string) error {
+func (tv *TestingVisitor) EnterField(ctx WalkContext, s StructInfo, field FieldInfo, tag StructTag) error {
 	if tv.Depth > 1 {
 		tv.Events = append(tv.Events, TestingVisitorEvent{Kind: "EnterField", Name: field.Name(), Depth: tv.Depth})
 	}
 	tv.Depth++
 	return nil
 }
-func (tv *TestingVisitor) LeaveField(ctx WalkContext, s StructInfo, field *types.Var, tag string) error {
+func (tv *TestingVisitor) LeaveField(ctx WalkContext, s StructInfo, field FieldInfo, tag StructTag) error {
 	tv.Depth--
 	if tv.Depth > 1 {
 		tv.Events = append(tv.Events, TestingVisitorEvent{Kind: "LeaveField", Name: field.Name(), Depth: tv.Depth})
@@ -370,6 +402,15 @@ func (tv *TestingVisitor) LeaveField(ctx WalkContext, s StructInfo, field *types
 	return nil
 }
 
+func (tv *TestingVisitor) EnterMap(ctx WalkContext, ft *types.Map) error {
+	tv.Events = append(tv.Events, TestingVisitorEvent{Kind: "EnterMap", Name: ft.String(), Depth: tv.Depth})
+	return nil
+}
+func (tv *TestingVisitor) LeaveMap(ctx WalkContext, ft *types.Map) error {
+	tv.Events = append(tv.Events, TestingVisitorEvent{Kind: "LeaveMap", Name: ft.String(), Depth: tv.Depth})
+	return nil
+}
+
 func (tv *TestingVisitor) EnterMapKey(ctx WalkContext, ft *types.Map, key types.Type) error {
 	tv.Events = append(tv.Events, TestingVisitorEvent{Kind: "EnterMapKey", Name: ft.String(), Depth: tv.Depth})
 	tv.Depth++
@@ -440,7 +481,366 @@ func (tv *TestingVisitor) VisitNamed(ctx WalkContext, t *types.Named) error {
 	return nil
 }
 
-func (tv *TestingVisitor) VisitInterface(ctx WalkContext, t *types.Interface) error {
-	tv.Events = append(tv.Events, TestingVisitorEvent{Kind: "VisitInterface", Name: t.String(), Depth: tv.Depth})
+func (tv *TestingVisitor) EnterInterface(ctx WalkContext, t *types.Interface) error {
+	tv.Events = append(tv.Events, TestingVisitorEvent{Kind: "EnterInterface", Name: t.String(), Depth: tv.Depth})
+	tv.Depth++
 	return nil
 }
+func (tv *TestingVisitor) LeaveInterface(ctx WalkContext, t *types.Interface) error {
+	tv.Depth--
+	tv.Events = append(tv.Events, TestingVisitorEvent{Kind: "LeaveInterface", Name: t.String(), Depth: tv.Depth})
+	return nil
+}
+
+func (tv *TestingVisitor) VisitMethod(ctx WalkContext, iface *types.Interface, method MethodInfo) error {
+	tv.Events = append(tv.Events, TestingVisitorEvent{Kind: "VisitMethod", Name: method.Name, Depth: tv.Depth})
+	return nil
+}
+
+func (tv *TestingVisitor) VisitEmbedded(ctx WalkContext, iface *types.Interface, embedded types.Type) error {
+	tv.Events = append(tv.Events, TestingVisitorEvent{Kind: "VisitEmbedded", Name: embedded.String(), Depth: tv.Depth})
+	return nil
+}
+
+func (tv *TestingVisitor) EnterImplementer(ctx WalkContext, iface types.Type, impl Implementer) error {
+	tv.Events = append(tv.Events, TestingVisitorEvent{Kind: "EnterImplementer", Name: impl.Type.String(), Depth: tv.Depth})
+	tv.Depth++
+	return nil
+}
+func (tv *TestingVisitor) LeaveImplementer(ctx WalkContext, iface types.Type, impl Implementer) error {
+	tv.Depth--
+	tv.Events = append(tv.Events, TestingVisitorEvent{Kind: "LeaveImplementer", Name: impl.Type.String(), Depth: tv.Depth})
+	return nil
+}
+
+type TestingControlStruct struct {
+	Struct  struct{ A int }
+	Map     map[string]int
+	Pointer *int
+	Slice   []int
+	Array   [1]int
+
+	Interface interface{ Foo() }
+}
+
+// controlVisitor records every event as "Kind path" and returns the error
+// in Returns that matches the event, if any.
+type controlVisitor struct {
+	Events  []string
+	Returns map[string]error
+}
+
+func (cv *controlVisitor) event(ctx WalkContext, kind string) error {
+	ev := kind + " " + ctx.Path().Expr("v")
+	cv.Events = append(cv.Events, ev)
+	return cv.Returns[ev]
+}
+
+func (cv *controlVisitor) EnterStruct(ctx WalkContext, s StructInfo) error {
+	return cv.event(ctx, "EnterStruct")
+}
+func (cv *controlVisitor) LeaveStruct(ctx WalkContext, s StructInfo) error {
+	return cv.event(ctx, "LeaveStruct")
+}
+func (cv *controlVisitor) EnterField(ctx WalkContext, s StructInfo, field FieldInfo, tag StructTag) error {
+	return cv.event(ctx, "EnterField")
+}
+func (cv *controlVisitor) LeaveField(ctx WalkContext, s StructInfo, field FieldInfo, tag StructTag) error {
+	return cv.event(ctx, "LeaveField")
+}
+func (cv *controlVisitor) EnterMap(ctx WalkContext, ft *types.Map) error {
+	return cv.event(ctx, "EnterMap")
+}
+func (cv *controlVisitor) LeaveMap(ctx WalkContext, ft *types.Map) error {
+	return cv.event(ctx, "LeaveMap")
+}
+func (cv *controlVisitor) EnterMapKey(ctx WalkContext, ft *types.Map, key types.Type) error {
+	return cv.event(ctx, "EnterMapKey")
+}
+func (cv *controlVisitor) LeaveMapKey(ctx WalkContext, ft *types.Map, key types.Type) error {
+	return cv.event(ctx, "LeaveMapKey")
+}
+func (cv *controlVisitor) EnterMapElem(ctx WalkContext, ft *types.Map, elem types.Type) error {
+	return cv.event(ctx, "EnterMapElem")
+}
+func (cv *controlVisitor) LeaveMapElem(ctx WalkContext, ft *types.Map, elem types.Type) error {
+	return cv.event(ctx, "LeaveMapElem")
+}
+func (cv *controlVisitor) EnterPointer(ctx WalkContext, ft *types.Pointer) error {
+	return cv.event(ctx, "EnterPointer")
+}
+func (cv *controlVisitor) LeavePointer(ctx WalkContext, ft *types.Pointer) error {
+	return cv.event(ctx, "LeavePointer")
+}
+func (cv *controlVisitor) EnterSlice(ctx WalkContext, ft *types.Slice) error {
+	return cv.event(ctx, "EnterSlice")
+}
+func (cv *controlVisitor) LeaveSlice(ctx WalkContext, ft *types.Slice) error {
+	return cv.event(ctx, "LeaveSlice")
+}
+func (cv *controlVisitor) EnterArray(ctx WalkContext, ft *types.Array) error {
+	return cv.event(ctx, "EnterArray")
+}
+func (cv *controlVisitor) LeaveArray(ctx WalkContext, ft *types.Array) error {
+	return cv.event(ctx, "LeaveArray")
+}
+func (cv *controlVisitor) VisitBasic(ctx WalkContext, t *types.Basic) error {
+	return cv.event(ctx, "VisitBasic")
+}
+func (cv *controlVisitor) VisitInvalid(ctx WalkContext, root TypeName, t *types.Basic) error {
+	return cv.event(ctx, "VisitInvalid")
+}
+func (cv *controlVisitor) VisitNamed(ctx WalkContext, t *types.Named) error {
+	return cv.event(ctx, "VisitNamed")
+}
+func (cv *controlVisitor) EnterInterface(ctx WalkContext, t *types.Interface) error {
+	return cv.event(ctx, "EnterInterface")
+}
+func (cv *controlVisitor) LeaveInterface(ctx WalkContext, t *types.Interface) error {
+	return cv.event(ctx, "LeaveInterface")
+}
+func (cv *controlVisitor) VisitMethod(ctx WalkContext, iface *types.Interface, method MethodInfo) error {
+	return cv.event(ctx, "VisitMethod")
+}
+func (cv *controlVisitor) VisitEmbedded(ctx WalkContext, iface *types.Interface, embedded types.Type) error {
+	return cv.event(ctx, "VisitEmbedded")
+}
+func (cv *controlVisitor) EnterImplementer(ctx WalkContext, iface types.Type, impl Implementer) error {
+	return cv.event(ctx, "EnterImplementer")
+}
+func (cv *controlVisitor) LeaveImplementer(ctx WalkContext, iface types.Type, impl Implementer) error {
+	return cv.event(ctx, "LeaveImplementer")
+}
+
+func TestPartialTypeVisitorPointer(t *testing.T) {
+	ptr := types.NewPointer(types.Typ[types.Int])
+
+	var p PartialTypeVisitor
+	if err := p.EnterPointer(nil, ptr); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.LeavePointer(nil, ptr); err != nil {
+		t.Fatal(err)
+	}
+
+	// The funcs must be called, rather than the methods calling themselves.
+	var calls []string
+	p.EnterPointerFunc = func(ctx WalkContext, t *types.Pointer) error {
+		calls = append(calls, "enter")
+		return nil
+	}
+	p.LeavePointerFunc = func(ctx WalkContext, t *types.Pointer) error {
+		calls = append(calls, "leave")
+		return SkipChildren
+	}
+	if err := p.EnterPointer(nil, ptr); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.LeavePointer(nil, ptr); err != SkipChildren {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual([]string{"enter", "leave"}, calls) {
+		t.Fatal(calls)
+	}
+}
+
+func TestWalkControl(t *testing.T) {
+	_, tn, tst := getTestingStruct(t, "github.com/shabbyrobe/structer.TestingControlStruct")
+
+	full := &controlVisitor{}
+	if err := Walk(tn, tst, full); err != nil {
+		t.Fatal(err)
+	}
+
+	// Find the index of the Leave event that matches the Enter event at idx.
+	leaveFor := func(idx int) int {
+		enter := full.Events[idx]
+		leave := "Leave" + strings.TrimPrefix(enter, "Enter")
+		for i := idx + 1; i < len(full.Events); i++ {
+			if full.Events[i] == leave {
+				return i
+			}
+		}
+		t.Fatalf("no leave event found for %s", enter)
+		return -1
+	}
+
+	kinds := map[string]bool{}
+	for idx, enter := range full.Events {
+		if !strings.HasPrefix(enter, "Enter") {
+			continue
+		}
+		kinds[strings.Fields(enter)[0]] = true
+		leave := leaveFor(idx)
+
+		for _, tc := range []struct {
+			ret error
+			exp []string
+		}{
+			{SkipChildren, append(append([]string{}, full.Events[:idx+1]...), full.Events[leave:]...)},
+			{SkipNode, append(append([]string{}, full.Events[:idx+1]...), full.Events[leave+1:]...)},
+			{WalkOver, append(append([]string{}, full.Events[:idx+1]...), full.Events[leave+1:]...)},
+			{StopWalk, full.Events[:idx+1]},
+		} {
+			vis := &controlVisitor{Returns: map[string]error{enter: tc.ret}}
+			if err := Walk(tn, tst, vis); err != nil {
+				t.Fatalf("%s %v: unexpected error %v", enter, tc.ret, err)
+			}
+			if !reflect.DeepEqual(tc.exp, vis.Events) {
+				t.Fatalf("%s %v: unexpected events\n%s", enter, tc.ret, strings.Join(vis.Events, "\n"))
+			}
+		}
+	}
+
+	for _, kind := range []string{"EnterStruct", "EnterField", "EnterMap", "EnterMapKey", "EnterMapElem", "EnterPointer", "EnterSlice", "EnterArray", "EnterInterface"} {
+		if !kinds[kind] {
+			t.Fatalf("kind %s not tested", kind)
+		}
+	}
+}
+
+func TestWalkControlVisitStop(t *testing.T) {
+	_, tn, tst := getTestingStruct(t, "github.com/shabbyrobe/structer.TestingControlStruct")
+	vis := &controlVisitor{Returns: map[string]error{
+		"VisitBasic v.Struct.A": StopWalk,
+	}}
+	if err := Walk(tn, tst, vis); err != nil {
+		t.Fatal(err)
+	}
+	last := vis.Events[len(vis.Events)-1]
+	if last != "VisitBasic v.Struct.A" {
+		t.Fatal(last)
+	}
+}
+
+func TestWalkControlLeaveSkipIgnored(t *testing.T) {
+	_, tn, tst := getTestingStruct(t, "github.com/shabbyrobe/structer.TestingControlStruct")
+	full := &controlVisitor{}
+	if err := Walk(tn, tst, full); err != nil {
+		t.Fatal(err)
+	}
+	vis := &controlVisitor{Returns: map[string]error{
+		"LeaveField v.Struct":  SkipNode,
+		"VisitBasic v.Map[k1]": SkipChildren,
+	}}
+	if err := Walk(tn, tst, vis); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(full.Events, vis.Events) {
+		t.Fatal()
+	}
+}
+
+func TestWalkInterfaceMethodDoc(t *testing.T) {
+	pkg := "github.com/shabbyrobe/structer/testpkg/iface"
+	tpset := NewTypePackageSet()
+	if _, err := tpset.Import(pkg); err != nil {
+		t.Fatal(err)
+	}
+	tn := NewTypeName(pkg, "Service")
+	typ := tpset.MustFindObject(tn).Type().Underlying()
+
+	var methods []MethodInfo
+	var embeddeds []string
+	vis := &PartialTypeVisitor{
+		VisitMethodFunc: func(ctx WalkContext, iface *types.Interface, method MethodInfo) error {
+			methods = append(methods, method)
+			return nil
+		},
+		VisitEmbeddedFunc: func(ctx WalkContext, iface *types.Interface, embedded types.Type) error {
+			embeddeds = append(embeddeds, embedded.String())
+			return nil
+		},
+	}
+	if err := Walk(tn, typ, vis, WalkTypePackageSet(tpset)); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual([]string{"io.Closer"}, embeddeds) {
+		t.Fatal(embeddeds)
+	}
+	if len(methods) != 1 {
+		t.Fatal(len(methods))
+	}
+	if methods[0].Name != "Get" || methods[0].Doc != "Get fetches a thing.\n" {
+		t.Fatalf("%s %q", methods[0].Name, methods[0].Doc)
+	}
+	if methods[0].Signature.Params().Len() != 1 || methods[0].Signature.Results().Len() != 2 {
+		t.Fatal(methods[0].Signature)
+	}
+}
+
+func TestWalkPositions(t *testing.T) {
+	pkg := "github.com/shabbyrobe/structer/testpkg/positions"
+	tpset := NewTypePackageSet()
+	if _, err := tpset.Import(pkg); err != nil {
+		t.Fatal(err)
+	}
+	tn := NewTypeName(pkg, "Positions")
+	typ := tpset.MustFindObject(tn).Type().Underlying()
+
+	var structs []string
+	var fields []string
+	vis := &PartialTypeVisitor{
+		EnterStructFunc: func(ctx WalkContext, s StructInfo) error {
+			if s.AST == nil || !strings.HasSuffix(s.Position.Filename, "positions.go") {
+				t.Fatalf("missing AST for struct %s", s.Name)
+			}
+			structs = append(structs, fmt.Sprintf("%s:%d", s.Name, s.Position.Line))
+			return nil
+		},
+		EnterFieldFunc: func(ctx WalkContext, s StructInfo, field FieldInfo, tag StructTag) error {
+			if field.AST == nil {
+				t.Fatalf("missing AST for field %s", field.Name())
+			}
+			src, err := tpset.ASTPackages.NodeSource(pkg, field.TypeExpr())
+			if err != nil {
+				t.Fatal(err)
+			}
+			fields = append(fields, fmt.Sprintf("%s:%d:%d %s", field.Name(), field.Position.Line, field.Position.Column, src))
+			return nil
+		},
+	}
+	if err := Walk(tn, typ, vis, WalkTypePackageSet(tpset)); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual([]string{"Positions:11", "Nested:16"}, structs) {
+		t.Fatal(structs)
+	}
+	expected := []string{
+		"When:12:2 stdtime.Time",
+		"Paren:13:2 (*int)",
+		"A:14:2 string",
+		"B:14:5 string",
+		"Embedded:15:3 *Embedded",
+		"Nested:16:2 struct {\n\t\tDeep []map[string]stdtime.Duration\n\t}",
+		"Deep:17:3 []map[string]stdtime.Duration",
+	}
+	if !reflect.DeepEqual(expected, fields) {
+		t.Fatalf("%q", fields)
+	}
+}
+
+func TestWalkPositionsEmptyRoot(t *testing.T) {
+	pkg := "github.com/shabbyrobe/structer/testpkg/positions"
+	tpset := NewTypePackageSet()
+	if _, err := tpset.Import(pkg); err != nil {
+		t.Fatal(err)
+	}
+	tn := NewTypeName(pkg, "Empty")
+	typ := tpset.MustFindObject(tn).Type().Underlying()
+
+	var sinfo StructInfo
+	vis := &PartialTypeVisitor{
+		EnterStructFunc: func(ctx WalkContext, s StructInfo) error {
+			sinfo = s
+			return nil
+		},
+	}
+	if err := Walk(tn, typ, vis, WalkTypePackageSet(tpset)); err != nil {
+		t.Fatal(err)
+	}
+	if sinfo.AST == nil || sinfo.Position.Line != 21 {
+		t.Fatal(sinfo.Position)
+	}
+}
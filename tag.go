@@ -0,0 +1,286 @@
+package structer
+
+import (
+	"errors"
+	"fmt"
+	"go/token"
+	"go/types"
+	"strconv"
+	"strings"
+)
+
+// Struct tag validation errors. All but ErrTagDuplicate match the errors
+// reported by go vet's structtag check. ErrTagDuplicate is an extra check:
+// vet does not complain about a key that appears twice in the same tag.
+var (
+	ErrTagSyntax      = errors.New("bad syntax for struct tag pair")
+	ErrTagKeySyntax   = errors.New("bad syntax for struct tag key")
+	ErrTagValueSyntax = errors.New("bad syntax for struct tag value")
+	ErrTagValueSpace  = errors.New("suspicious space in struct tag value")
+	ErrTagSpace       = errors.New("key:\"value\" pairs not separated by spaces")
+	ErrTagDuplicate   = errors.New("duplicate struct tag key")
+)
+
+// Keys for which go vet checks the value for suspicious spaces.
+var checkTagSpaces = map[string]bool{"json": true, "xml": true, "asn1": true}
+
+// StructTag is a parsed struct field tag.
+type StructTag struct {
+	Raw string
+
+	// Keys in the order they appear in the tag.
+	Keys []TagKey
+
+	// Err contains the syntax error that prevented the rest of the tag from
+	// being parsed, if any. Keys contains all keys parsed before the error.
+	Err error
+}
+
+// TagKey is a single key:"value" pair from a StructTag.
+type TagKey struct {
+	Key string
+
+	// Value is the complete unquoted value, i.e. "name,omitempty".
+	Value string
+
+	// Name is the part of Value before the first comma, i.e. "name".
+	Name string
+
+	// Options contains the comma separated parts of Value after Name, i.e.
+	// []string{"omitempty"}.
+	Options []string
+
+	// Err contains a validation error for this key, if any.
+	Err error
+}
+
+// HasOption reports whether opt is one of the key's options.
+func (k TagKey) HasOption(opt string) bool {
+	for _, o := range k.Options {
+		if o == opt {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseStructTag parses a raw struct tag, validating it using the same rules
+// as go vet's structtag check, plus the extra check for duplicate keys.
+//
+// Parsing stops at the first syntax error, which is stored in StructTag.Err.
+// Suspicious values and duplicate keys do not stop parsing; the error is
+// stored in TagKey.Err instead.
+//
+func ParseStructTag(raw string) StructTag {
+	st := StructTag{Raw: raw}
+	seen := map[string]bool{}
+
+	// This is based on the StructTag.Get code in package reflect, and the
+	// validateStructTag code in go vet.
+	tag := raw
+	for n := 0; tag != ""; n++ {
+		if n > 0 && tag[0] != ' ' {
+			st.Err = ErrTagSpace
+			return st
+		}
+
+		i := 0
+		for i < len(tag) && tag[i] == ' ' {
+			i++
+		}
+		tag = tag[i:]
+		if tag == "" {
+			break
+		}
+
+		i = 0
+		for i < len(tag) && tag[i] > ' ' && tag[i] != ':' && tag[i] != '"' && tag[i] != 0x7f {
+			i++
+		}
+		if i == 0 {
+			st.Err = ErrTagKeySyntax
+			return st
+		}
+		if i+1 >= len(tag) || tag[i] != ':' {
+			st.Err = ErrTagSyntax
+			return st
+		}
+		if tag[i+1] != '"' {
+			st.Err = ErrTagValueSyntax
+			return st
+		}
+		key := tag[:i]
+		tag = tag[i+1:]
+
+		i = 1
+		for i < len(tag) && tag[i] != '"' {
+			if tag[i] == '\\' {
+				i++
+			}
+			i++
+		}
+		if i >= len(tag) {
+			st.Err = ErrTagValueSyntax
+			return st
+		}
+		qvalue := tag[:i+1]
+		tag = tag[i+1:]
+
+		value, err := strconv.Unquote(qvalue)
+		if err != nil {
+			st.Err = ErrTagValueSyntax
+			return st
+		}
+
+		tk := TagKey{Key: key, Value: value}
+		tk.Name, tk.Options = splitTagValue(value)
+		if seen[key] {
+			tk.Err = ErrTagDuplicate
+		} else if checkTagSpaces[key] {
+			tk.Err = validateTagValueSpaces(key, value)
+		}
+		seen[key] = true
+		st.Keys = append(st.Keys, tk)
+	}
+
+	return st
+}
+
+func validateTagValueSpaces(key, value string) error {
+	switch key {
+	case "xml":
+		// If the first or last character in the XML tag is a space, or
+		// there are multiple spaces, it is suspicious.
+		if strings.Trim(value, " ") != value || strings.Count(value, " ") > 1 {
+			return ErrTagValueSpace
+		}
+		comma := strings.IndexRune(value, ',')
+		if comma < 0 {
+			return nil
+		}
+		if comma > 0 && value[comma-1] == ' ' {
+			return ErrTagValueSpace
+		}
+		value = value[comma+1:]
+
+	case "json":
+		// JSON allows using spaces in the name, so skip it.
+		comma := strings.IndexRune(value, ',')
+		if comma < 0 {
+			return nil
+		}
+		value = value[comma+1:]
+	}
+
+	if strings.IndexByte(value, ' ') >= 0 {
+		return ErrTagValueSpace
+	}
+	return nil
+}
+
+// Lookup returns the first TagKey matching key.
+func (t StructTag) Lookup(key string) (tk TagKey, ok bool) {
+	for _, k := range t.Keys {
+		if k.Key == key {
+			return k, true
+		}
+	}
+	return tk, false
+}
+
+// Get returns the value associated with key, or an empty string, like
+// reflect.StructTag.Get.
+func (t StructTag) Get(key string) string {
+	k, _ := t.Lookup(key)
+	return k.Value
+}
+
+// Errors returns all errors found when parsing the tag.
+func (t StructTag) Errors() []error {
+	var errs []error
+	for _, k := range t.Keys {
+		if k.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", k.Key, k.Err))
+		}
+	}
+	if t.Err != nil {
+		errs = append(errs, t.Err)
+	}
+	return errs
+}
+
+func (t StructTag) String() string { return t.Raw }
+
+// splitTagValue splits a tag value like "name,omitempty" into its name and
+// options.
+func splitTagValue(value string) (name string, opts []string) {
+	parts := strings.Split(value, ",")
+	if len(parts) == 1 {
+		return parts[0], nil
+	}
+	return parts[0], parts[1:]
+}
+
+// Diagnostic is a problem found while walking a type that does not prevent
+// the walk from continuing, like a malformed struct tag.
+type Diagnostic struct {
+	Pos token.Pos
+
+	// Position is only populated if a TypePackageSet is passed to Walk using
+	// WalkTypePackageSet.
+	Position token.Position
+
+	Message string
+}
+
+func (d Diagnostic) String() string {
+	if d.Position.IsValid() {
+		return fmt.Sprintf("%s: %s", d.Position, d.Message)
+	}
+	return d.Message
+}
+
+// structTagDiagnostics checks the tags of all of the fields in s using the
+// same rules as go vet's structtag check.
+func structTagDiagnostics(s *types.Struct) []Diagnostic {
+	var diags []Diagnostic
+
+	seen := map[string]map[string]*types.Var{"json": {}, "xml": {}}
+
+	for i := 0; i < s.NumFields(); i++ {
+		field := s.Field(i)
+		tag := ParseStructTag(s.Tag(i))
+		for _, err := range tag.Errors() {
+			diags = append(diags, Diagnostic{
+				Pos:     field.Pos(),
+				Message: fmt.Sprintf("struct field tag %q not compatible with reflect.StructTag.Get: %v", tag.Raw, err),
+			})
+		}
+
+		for _, key := range []string{"json", "xml"} {
+			tk, ok := tag.Lookup(key)
+			if !ok {
+				continue
+			}
+			if !field.Exported() && !field.Anonymous() {
+				diags = append(diags, Diagnostic{
+					Pos:     field.Pos(),
+					Message: fmt.Sprintf("struct field %s has %s tag but is not exported", field.Name(), key),
+				})
+				continue
+			}
+			if tk.Name == "" || tk.Name == "-" || (key == "xml" && tk.HasOption("attr")) {
+				continue
+			}
+			if other := seen[key][tk.Name]; other != nil {
+				diags = append(diags, Diagnostic{
+					Pos:     field.Pos(),
+					Message: fmt.Sprintf("struct field %s repeats %s tag %q also at field %s", field.Name(), key, tk.Name, other.Name()),
+				})
+				continue
+			}
+			seen[key][tk.Name] = field
+		}
+	}
+	return diags
+}
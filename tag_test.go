@@ -0,0 +1,114 @@
+package structer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseStructTag(t *testing.T) {
+	tag := ParseStructTag(`json:"foo,omitempty,string" msgpack:"-" yaml:""`)
+	if tag.Err != nil {
+		t.Fatal(tag.Err)
+	}
+	expected := []TagKey{
+		{Key: "json", Value: "foo,omitempty,string", Name: "foo", Options: []string{"omitempty", "string"}},
+		{Key: "msgpack", Value: "-", Name: "-"},
+		{Key: "yaml", Value: "", Name: ""},
+	}
+	if !reflect.DeepEqual(expected, tag.Keys) {
+		t.Fatalf("%#v", tag.Keys)
+	}
+	if !tag.Keys[0].HasOption("string") || tag.Keys[0].HasOption("foo") {
+		t.Fatal()
+	}
+	if tag.Get("msgpack") != "-" || tag.Get("nope") != "" {
+		t.Fatal()
+	}
+}
+
+func TestParseStructTagErrors(t *testing.T) {
+	for _, tc := range []struct {
+		tag    string
+		err    error
+		keyErr error
+	}{
+		{`json:"foo"`, nil, nil},
+		{`json:"foo" `, nil, nil},
+		{`json:"foo"xml:"bar"`, ErrTagSpace, nil},
+		{`:"foo"`, ErrTagKeySyntax, nil},
+		{`json`, ErrTagSyntax, nil},
+		{`json:foo`, ErrTagValueSyntax, nil},
+		{`json:"foo`, ErrTagValueSyntax, nil},
+		{`json:"foo, omitempty"`, nil, ErrTagValueSpace},
+		{`json:"foo bar,omitempty"`, nil, nil},
+		{`xml:" foo"`, nil, ErrTagValueSpace},
+		{`asn1:"foo bar"`, nil, ErrTagValueSpace},
+		{`other:"foo bar"`, nil, nil},
+		{`json:"foo" json:"bar"`, nil, ErrTagDuplicate},
+	} {
+		tag := ParseStructTag(tc.tag)
+		if tag.Err != tc.err {
+			t.Fatalf("%s: expected error %v, found %v", tc.tag, tc.err, tag.Err)
+		}
+		var keyErr error
+		for _, k := range tag.Keys {
+			if k.Err != nil {
+				keyErr = k.Err
+			}
+		}
+		if keyErr != tc.keyErr {
+			t.Fatalf("%s: expected key error %v, found %v", tc.tag, tc.keyErr, keyErr)
+		}
+	}
+}
+
+func TestWalkTagDiagnostics(t *testing.T) {
+	pkg := "github.com/shabbyrobe/structer/testpkg/tags"
+	tpset := NewTypePackageSet()
+	if _, err := tpset.Import(pkg); err != nil {
+		t.Fatal(err)
+	}
+	tn := NewTypeName(pkg, "Tags")
+	typ := tpset.MustFindObject(tn).Type().Underlying()
+
+	var diags []Diagnostic
+	var tags []StructTag
+	vis := &PartialTypeVisitor{
+		EnterFieldFunc: func(ctx WalkContext, s StructInfo, field FieldInfo, tag StructTag) error {
+			tags = append(tags, tag)
+			return nil
+		},
+	}
+	err := Walk(tn, typ, vis, WalkTypePackageSet(tpset), WalkDiagnostics(func(d Diagnostic) {
+		diags = append(diags, d)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tags[0].Get("xml") != "good" {
+		t.Fatal(tags[0])
+	}
+
+	expected := []struct {
+		line int
+		msg  string
+	}{
+		{5, "suspicious space in struct tag value"},
+		{6, "pairs not separated by spaces"},
+		{7, `repeats json tag "good" also at field Good`},
+		{8, "has json tag but is not exported"},
+	}
+	if len(expected) != len(diags) {
+		t.Fatalf("expected %d diagnostics, found %d: %v", len(expected), len(diags), diags)
+	}
+	for i, e := range expected {
+		if diags[i].Position.Line != e.line || !strings.Contains(diags[i].Message, e.msg) {
+			t.Fatalf("unexpected diagnostic %d: %s", i, diags[i])
+		}
+		if !strings.HasSuffix(diags[i].Position.Filename, "tags.go") {
+			t.Fatal(diags[i].Position)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package anon
+
+type Pants struct {
+	Foo []struct {
+		Bar map[struct{ K string }]string
+	}
+	Point struct{ X, Y int }
+	Baz   *struct {
+		Qux struct{ Name string }
+	}
+}
+
+type Shirt struct {
+	Point struct{ X, Y int }
+	Other struct {
+		X, Y int `json:"x"`
+	}
+}
+
+type Pants_Baz struct{}
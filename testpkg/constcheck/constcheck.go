@@ -0,0 +1,30 @@
+package constcheck
+
+type Status int
+
+const (
+	StatusActive Status = iota + 1
+	StatusPending
+	_
+	_
+	StatusClosed
+	statusHidden
+
+	StatusOpen = StatusActive
+)
+
+type Kind string
+
+const (
+	KindA Kind = "a"
+	KindB Kind = "b"
+)
+
+// Mixed has no gaps, as MixedB uses the iota value skipped by MixedC.
+type Mixed int
+
+const (
+	MixedA Mixed = iota
+	MixedB Mixed = 10
+	MixedC Mixed = iota
+)
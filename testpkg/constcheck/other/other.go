@@ -0,0 +1,5 @@
+package other
+
+import "github.com/shabbyrobe/structer/testpkg/constcheck"
+
+const StatusArchived constcheck.Status = 100
@@ -0,0 +1,43 @@
+package constrender
+
+type Level int
+
+const (
+	LevelLow  Level = -1
+	LevelHigh Level = 1 << 40
+)
+
+type Ratio float64
+
+const (
+	Half  Ratio = 0.5
+	Third Ratio = 1.0 / 3
+	Whole Ratio = 1
+)
+
+type Tenth float32
+
+const TenthValue Tenth = 0.1
+
+type Name string
+
+const Quoted Name = "a \"b\"\n\tcé\x00"
+
+type small uint8
+
+const smallMax small = 255
+
+type Wave complex128
+
+const WaveValue Wave = 1.5 + 2i
+
+type Toggle bool
+
+const ToggleOn Toggle = true
+
+const (
+	Big         = 1 << 100
+	Pi          = 3.14159265358979323846264338327950288419716939937510582097494459
+	Large       = 1e300 * 1e300
+	Typed int64 = -9000000000
+)
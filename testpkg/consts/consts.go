@@ -56,3 +56,19 @@ const (
 	TestEnumPtr1 TestEnumPtr = "foo"
 	TestEnumPtr2 TestEnumPtr = "bar"
 )
+
+type TestEnumInt int
+
+func (TestEnumInt) IsEnum() {}
+
+const (
+	_ TestEnumInt = iota
+
+	//structer:name one
+	TestEnumInt1
+	TestEnumInt2 // two
+	TestEnumInt3
+
+	// TestEnumIntDefault is an alias for TestEnumInt2.
+	TestEnumIntDefault = TestEnumInt2
+)
@@ -0,0 +1,5 @@
+package ignored
+
+import "github.com/shabbyrobe/structer/testpkg/constsext"
+
+const Ignored constsext.Color = "ignored"
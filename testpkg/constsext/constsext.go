@@ -0,0 +1,8 @@
+package constsext
+
+type Color string
+
+const (
+	Red   Color = "red"
+	black Color = "black"
+)
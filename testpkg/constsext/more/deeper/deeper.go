@@ -0,0 +1,8 @@
+package deeper
+
+import "github.com/shabbyrobe/structer/testpkg/constsext"
+
+const (
+	Blue  constsext.Color = "blue"
+	white constsext.Color = "white"
+)
@@ -0,0 +1,5 @@
+package more
+
+import "github.com/shabbyrobe/structer/testpkg/constsext"
+
+const Green constsext.Color = "green"
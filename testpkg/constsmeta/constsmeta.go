@@ -0,0 +1,18 @@
+package constsmeta
+
+type Level int
+
+// Warn is declared before Debug but has a higher value.
+const Warn Level = 3
+
+const (
+	_ Level = iota
+
+	// Debug is for debugging.
+	Debug // debug comment
+	Info
+	Error Level = 1 << (iota + 4)
+	Fatal
+)
+
+const Trace, Verbose Level = -1, -2
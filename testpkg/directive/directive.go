@@ -0,0 +1,18 @@
+package directive
+
+type Directive struct {
+	// Name is the name.
+	//structer:validate required
+	Name string `json:"name"` // trailing
+
+	Nested struct {
+		// Deep is nested.
+		//structer:skip
+		//go:generate echo nope
+		Deep int //structer:default 1
+	}
+
+	// Plain has no directives.
+	// https://example.com is not a directive.
+	Plain int
+}
@@ -0,0 +1,46 @@
+package enums
+
+//structer:enum
+type Directive string
+
+const (
+	DirectiveA Directive = "a"
+	DirectiveB Directive = "b"
+)
+
+type Trailing int //structer:enum
+
+type Marker int
+
+func (Marker) IsDomainEnum() {}
+
+const (
+	MarkerA Marker = iota + 1
+	MarkerB
+)
+
+type Iota int
+
+const (
+	IotaA Iota = iota
+	IotaB
+	iotaC
+)
+
+type SplitIota int
+
+const (
+	SplitIotaA SplitIota = iota
+	SplitIotaB
+)
+
+const SplitIotaC SplitIota = 10
+
+type MixedIota int
+
+const (
+	MixedIotaA MixedIota = iota
+	MixedIotaB MixedIota = 5
+)
+
+type NoConsts int
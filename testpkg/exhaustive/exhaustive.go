@@ -0,0 +1,109 @@
+package exhaustive
+
+import "github.com/shabbyrobe/structer/testpkg/consts"
+
+type Color int
+
+func (Color) IsEnum() {}
+
+const (
+	Red Color = iota
+	Green
+	Blue
+	hidden
+
+	Crimson = Red
+)
+
+func Full(c Color) {
+	switch c {
+	case Red, Green:
+	case Blue, hidden:
+	}
+}
+
+func Missing(c Color) string {
+	switch c {
+	case Crimson:
+		return "red"
+	}
+	return ""
+}
+
+func Default(c Color) {
+	switch c {
+	case Red:
+	default:
+	}
+}
+
+func NotEnum(i int) {
+	switch i {
+	case 1:
+	}
+}
+
+func Imported(e consts.TestEnum) {
+	switch e {
+	case consts.TestEnum1:
+	}
+}
+
+type Shape interface {
+	isShape()
+}
+
+type Circle struct{}
+
+func (Circle) isShape() {}
+
+type Square struct{}
+
+func (*Square) isShape() {}
+
+type Triangle struct{}
+
+func (Triangle) isShape() {}
+
+func TypeSwitchMissing(s Shape) {
+	switch s.(type) {
+	case Circle, *Triangle:
+	case nil:
+	}
+}
+
+func TypeSwitchFull(s Shape) {
+	switch x := s.(type) {
+	case Circle, *Square, Triangle:
+		_ = x
+	}
+}
+
+type Open interface {
+	Area() float64
+}
+
+func (Circle) Area() float64 { return 0 }
+
+func OpenSwitch(o Open) {
+	switch o.(type) {
+	}
+}
+
+//structer:sealed
+type Sealed interface {
+	Area() float64
+}
+
+func SealedSwitch(s Sealed) {
+	switch s.(type) {
+	}
+}
+
+// PointerCaseOnly does not handle a Circle value, as it only matches *Circle.
+func PointerCaseOnly(s Shape) {
+	switch s.(type) {
+	case *Circle:
+	case *Square, Triangle:
+	}
+}
@@ -0,0 +1,62 @@
+package flags
+
+type Perm uint8
+
+const (
+	PermNone Perm = 0
+	PermRead Perm = 1 << iota
+	PermWrite
+	PermExec
+
+	PermReadWrite = PermRead | PermWrite
+)
+
+//structer:flags
+type Mode int
+
+const (
+	ModeA Mode = 1
+	ModeB Mode = 2
+)
+
+// Level looks like flags for the first few values.
+type Level int
+
+const (
+	LevelZero Level = iota
+	LevelOne
+	LevelTwo
+	LevelThree
+	LevelFour
+)
+
+type NotFlags int
+
+const (
+	NotFlagsA NotFlags = 1
+	NotFlagsB NotFlags = 2
+	NotFlagsC NotFlags = 4
+	NotFlagsD NotFlags = 10
+)
+
+// Shape is an enum whose values happen to be powers of two.
+//
+//structer:enum
+type Shape int
+
+const (
+	ShapeCircle   Shape = 1
+	ShapeSquare   Shape = 2
+	ShapeTriangle Shape = 4
+)
+
+// Option is an enum that is also marked as a set of flags.
+//
+//structer:enum
+//structer:flags
+type Option int
+
+const (
+	OptionA Option = 1
+	OptionB Option = 2
+)
@@ -0,0 +1,10 @@
+package iface
+
+import "io"
+
+type Service interface {
+	io.Closer
+
+	// Get fetches a thing.
+	Get(id string) (string, error)
+}
@@ -0,0 +1,26 @@
+package impl
+
+type Shape interface {
+	Area() float64
+}
+
+type Anything interface{}
+
+type Circle struct {
+	Radius float64
+}
+
+func (c Circle) Area() float64 { return 0 }
+
+type Square struct {
+	Side  float64
+	Inner Shape
+}
+
+func (s *Square) Area() float64 { return 0 }
+
+type Drawing struct {
+	Shape  Shape
+	Inline interface{ Area() float64 }
+	Any    interface{}
+}
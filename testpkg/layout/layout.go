@@ -0,0 +1,23 @@
+package layout
+
+type Padded struct {
+	A bool
+	B int64
+	C bool
+	D int32
+}
+
+type Packed struct {
+	B int64
+	D int32
+	A bool
+	C bool
+}
+
+type Nested struct {
+	A    bool
+	Anon struct {
+		X bool
+		Y int64
+	}
+}
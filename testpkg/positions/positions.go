@@ -0,0 +1,21 @@
+package positions
+
+import (
+	stdtime "time"
+)
+
+type Embedded struct {
+	Inner int
+}
+
+type Positions struct {
+	When  stdtime.Time // aliased import
+	Paren (*int)
+	A, B  string
+	*Embedded
+	Nested struct {
+		Deep []map[string]stdtime.Duration
+	}
+}
+
+type Empty struct{}
@@ -0,0 +1,51 @@
+package promote
+
+type Base struct {
+	ID   int
+	Name string
+}
+
+type Other struct {
+	Name  string
+	Extra int
+}
+
+// Simple promotes Base's fields. Base.Name is shadowed by Simple.Name.
+type Simple struct {
+	Base
+	Name string
+}
+
+// Ambiguous embeds two structs with a Name field at the same depth.
+type Ambiguous struct {
+	Base
+	*Other
+}
+
+type Tagged struct {
+	Base
+	Other   `json:"other"`
+	Title   string `json:"Name"`
+	Skipped string `json:"-"`
+	hidden  string
+}
+
+type TaggedA struct {
+	X int `json:"X"`
+}
+
+type TaggedB struct {
+	X int
+}
+
+// TagWins contains two fields named X at the same depth, but only one is
+// tagged.
+type TagWins struct {
+	TaggedA
+	TaggedB
+}
+
+type Node struct {
+	*Node
+	Val int
+}
@@ -0,0 +1,30 @@
+package reorder
+
+// Wasteful wastes bytes.
+type Wasteful struct {
+	// A is a bool.
+	A bool `json:"a"`
+
+	B    int64 // B is an int64
+	C, D bool
+
+	// E is an int32.
+	E int32 `json:"e,omitempty"`
+
+	// Trailing comment
+}
+
+type Optimal struct {
+	B int64
+	E int32
+	A bool
+}
+
+type Inline struct {
+	A bool
+	B int64
+}
+
+// Derived has the same wasteful layout, but its fields can only be reordered
+// in Wasteful.
+type Derived Wasteful
@@ -0,0 +1,12 @@
+package reorder
+
+// This file is deliberately not gofmt-ed, so that the ";" separators between
+// fields are kept.
+
+type SingleLine struct{ A bool; B int64; C bool }
+
+type Separated struct {
+	A bool; B int64 // B is an int64
+	// C is a bool.
+	C bool;
+}
@@ -0,0 +1,37 @@
+package schema
+
+import "time"
+
+// Color is a closed set of colours.
+type Color string
+
+func (Color) IsEnum() {}
+
+const (
+	// Red is red.
+	Red   Color = "red"
+	Green Color = "green"
+)
+
+// Shape is something that can be drawn.
+type Shape interface {
+	// Area returns the area of the shape.
+	Area() float64
+}
+
+type Circle struct {
+	Radius float64
+}
+
+func (c Circle) Area() float64 { return 0 }
+
+// Drawing is the root of the schema.
+type Drawing struct {
+	// Title of the drawing.
+	Title   string `json:"title"`
+	Color   Color
+	Shapes  []Shape
+	Parent  *Drawing
+	Layers  map[string][2]int
+	Created time.Time
+}
@@ -0,0 +1,9 @@
+package tags
+
+type Tags struct {
+	Good    string `json:"good,omitempty" xml:"good"`
+	Space   string `json:"space,omitempty "`
+	Bad     string `json:"bad"xml:"bad"`
+	Dupe    string `json:"good"`
+	private string `json:"private"`
+}
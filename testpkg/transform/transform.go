@@ -0,0 +1,15 @@
+package transform
+
+import "time"
+
+type Event struct {
+	ID     int64     `json:"id"`
+	When   time.Time `json:"when"`
+	Tags   []string
+	Meta   map[string]time.Time
+	Ref    *Event
+	secret string
+	Nested struct {
+		At time.Time `json:"at"`
+	}
+}
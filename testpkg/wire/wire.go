@@ -0,0 +1,21 @@
+package wire
+
+type Base struct {
+	ID        int    `json:"id" yaml:"id" bson:"_id"`
+	CreatedAt string `json:"created_at,omitempty" msgpack:"created_at,omitempty"`
+}
+
+type Meta struct {
+	Labels map[string]string
+}
+
+type Record struct {
+	Base
+	Meta     `json:"meta" yaml:",inline" bson:",inline"`
+	HTTPPort int    `json:",omitzero" msgpack:"port"`
+	UserName string `yaml:"user,omitempty"`
+	Dash     string `json:"-," msgpack:"-"`
+	Ignored  string `json:"-" yaml:"-" bson:"-" msgpack:"-"`
+	private  string
+	Deleted  *bool `json:",omitempty,omitzero"`
+}
@@ -5,9 +5,12 @@ import (
 	"go/ast"
 	"go/build"
 	"go/importer"
+	"go/token"
 	"go/types"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 )
 
@@ -22,6 +25,10 @@ var (
 
 type Config struct {
 	IncludeTests bool
+
+	// GOARCH used to calculate struct layouts. If empty, BuildContext.GOARCH
+	// is used.
+	GOARCH string
 }
 
 type option func(*TypePackageSet)
@@ -63,6 +70,10 @@ type TypePackageSet struct {
 	// false to fail on hard errors too.
 	AllowHardTypesError bool
 
+	// EnumPolicy decides which types ExtractConsts and IsEnum consider to be
+	// enums. It defaults to DefaultEnumPolicy.
+	EnumPolicy EnumPolicy
+
 	Log Log
 }
 
@@ -75,6 +86,7 @@ func NewTypePackageSet(opts ...option) *TypePackageSet {
 		BuiltFiles:      make(map[string][]string),
 		Objects:         make(map[TypeName]types.Object),
 		Kinds:           make(map[string]PackageKind),
+		EnumPolicy:      DefaultEnumPolicy,
 	}
 	tps.AllowHardTypesError = true
 	tps.TypesConfig.IgnoreFuncBodies = false
@@ -195,11 +207,44 @@ func (t *TypePackageSet) ExtractSource(name TypeName) ([]byte, error) {
 // the same package.
 //
 // Go provides no language-level idea of an Enum constraint, so structer provides
-// the IsEnum interface. If the type satisfies IsEnum, it is assumed that the
-// range of constants expresses the limit of all possible values for that type.
-// Otherwise, it is just a bag of constants.
+// the IsEnum interface. If the type satisfies IsEnum, or is otherwise an enum
+// according to the EnumPolicy, it is assumed that the range of constants
+// expresses the limit of all possible values for that type. Otherwise, it is
+// just a bag of constants.
 //
 func (t *TypePackageSet) ExtractConsts(name TypeName, includeUnexported bool) (*Consts, error) {
+	return t.ExtractConstsWith(name, ConstsOptions{IncludeUnexported: includeUnexported})
+}
+
+// ConstsOptions controls ExtractConstsWith.
+type ConstsOptions struct {
+	IncludeUnexported bool
+
+	// AllPackages searches every package imported into the TypePackageSet
+	// for constants of the type, rather than just the type's own package.
+	AllPackages bool
+
+	// ImportPattern is passed to ImportPattern before searching, i.e.
+	// "example.com/project/...". It implies AllPackages.
+	ImportPattern string
+}
+
+// ExtractConstsWith finds the constants that have the type name. The
+// PackagePath of each ConstValue's Name is the package the constant was
+// declared in, which is not necessarily the package that declares the type
+// if AllPackages is used.
+//
+// Values are returned in declaration order. If AllPackages is used, the
+// packages are ordered by import path.
+//
+func (t *TypePackageSet) ExtractConstsWith(name TypeName, opts ConstsOptions) (*Consts, error) {
+	if opts.ImportPattern != "" {
+		if _, err := t.ImportPattern(opts.ImportPattern); err != nil {
+			return nil, err
+		}
+		opts.AllPackages = true
+	}
+
 	def := t.Objects[name]
 	if def == nil {
 		return nil, fmt.Errorf("could not find def for %s", name)
@@ -213,25 +258,21 @@ func (t *TypePackageSet) ExtractConsts(name TypeName, includeUnexported bool) (*
 	consts := &Consts{
 		Type:       name,
 		Underlying: ExtractTypeName(def.Type().Underlying()),
-		IsEnum:     isEnum(def, named),
+		IsEnum:     t.isEnum(name, def, named),
+		IsFlags:    t.isFlags(name, def),
 	}
 
-	for n, o := range t.Infos[name.PackagePath].Defs {
-		if o == nil {
-			continue
-		}
-		if !name.IsType(o.Type()) {
-			continue
-		}
-		if !includeUnexported && !n.IsExported() {
-			continue
-		}
-		if cns, ok := o.(*types.Const); ok {
-			consts.Values = append(consts.Values, &ConstValue{
-				Name:  NewTypeName(name.PackagePath, cns.Name()),
-				Value: cns.Val(),
-			})
+	pkgPaths := []string{name.PackagePath}
+	if opts.AllPackages {
+		pkgPaths = pkgPaths[:0]
+		for pkgPath := range t.Infos {
+			pkgPaths = append(pkgPaths, pkgPath)
 		}
+		sort.Strings(pkgPaths)
+	}
+
+	for _, pkgPath := range pkgPaths {
+		consts.Values = append(consts.Values, t.packageConsts(pkgPath, name, opts.IncludeUnexported)...)
 	}
 	return consts, nil
 }
@@ -359,6 +400,146 @@ func (t *TypePackageSet) Import(importPath string) (*types.Package, error) {
 	return t.ImportFrom(importPath, srcPath, 0)
 }
 
+// packageConsts finds the constants of the type name declared in pkgPath, in
+// the order they are declared. Files are visited in order of their names.
+func (t *TypePackageSet) packageConsts(pkgPath string, name TypeName, includeUnexported bool) []*ConstValue {
+	astPkg := t.ASTPackages.Packages[pkgPath]
+	defs := t.Infos[pkgPath].Defs
+	if astPkg == nil || defs == nil {
+		return nil
+	}
+
+	files := make([]string, 0, len(astPkg.AST.Files))
+	for file := range astPkg.AST.Files {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	var values []*ConstValue
+	for _, file := range files {
+		ast.Inspect(astPkg.AST.Files[file], func(node ast.Node) bool {
+			gd, ok := node.(*ast.GenDecl)
+			if !ok || gd.Tok != token.CONST {
+				return true
+			}
+
+			var last *ast.ValueSpec
+			for idx, spec := range gd.Specs {
+				vs := spec.(*ast.ValueSpec)
+				if len(vs.Values) > 0 {
+					last = vs
+				}
+				for i, ident := range vs.Names {
+					cns, ok := defs[ident].(*types.Const)
+					if !ok || !name.IsType(cns.Type()) {
+						continue
+					}
+					if !includeUnexported && !ident.IsExported() {
+						continue
+					}
+					cv := &ConstValue{
+						Name:       NewTypeName(pkgPath, cns.Name()),
+						Value:      cns.Val(),
+						Const:      cns,
+						Position:   t.ASTPackages.FileSet.Position(ident.Pos()),
+						Doc:        vs.Doc.Text(),
+						Comment:    vs.Comment.Text(),
+						Directives: ParseDirectives(vs.Doc, vs.Comment),
+						IotaIndex:  idx,
+						Implicit:   len(vs.Values) == 0,
+						decl:       gd,
+					}
+					if cv.Doc == "" && !gd.Lparen.IsValid() {
+						cv.Doc = gd.Doc.Text()
+					}
+					if last != nil && i < len(last.Values) {
+						expr := last.Values[i]
+						if src, err := t.ASTPackages.NodeSource(pkgPath, expr); err == nil {
+							cv.Expr = string(src)
+						}
+						cv.Iota = usesIota(expr)
+					}
+					values = append(values, cv)
+				}
+			}
+			return false
+		})
+	}
+	return values
+}
+
+func usesIota(expr ast.Expr) (found bool) {
+	ast.Inspect(expr, func(node ast.Node) bool {
+		if ident, ok := node.(*ast.Ident); ok && ident.Name == "iota" {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// ImportPattern imports every package matching pattern, which is either an
+// import path or an import path followed by "/..." to import it and every
+// package below it. As with the go tool, directories called "testdata" or
+// "vendor", or starting with "." or "_", are skipped, as are directories that
+// contain no buildable Go files.
+func (t *TypePackageSet) ImportPattern(pattern string) ([]*types.Package, error) {
+	base := strings.TrimSuffix(pattern, "/...")
+	if base == pattern {
+		pkg, err := t.Import(pattern)
+		if err != nil {
+			return nil, err
+		}
+		return []*types.Package{pkg}, nil
+	}
+
+	srcDir := filepath.Join(BuildContext.GOPATH, "src", base)
+	kind, dir, err := t.ResolvePath(base, srcDir)
+	if err != nil {
+		return nil, err
+	} else if kind == NoPackage {
+		return nil, fmt.Errorf("could not find package %s", base)
+	}
+
+	var importPaths []string
+	err = filepath.Walk(dir, func(file string, info os.FileInfo, err error) error {
+		if err != nil || !info.IsDir() {
+			return err
+		}
+		if file != dir {
+			name := info.Name()
+			if name == "testdata" || name == "vendor" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
+				return filepath.SkipDir
+			}
+		}
+		if _, err := BuildContext.ImportDir(file, 0); err != nil {
+			if _, ok := err.(*build.NoGoError); ok {
+				return nil
+			}
+			return err
+		}
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+		importPaths = append(importPaths, path.Join(base, filepath.ToSlash(rel)))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pkgs := make([]*types.Package, 0, len(importPaths))
+	for _, importPath := range importPaths {
+		pkg, err := t.Import(importPath)
+		if err != nil {
+			return nil, err
+		}
+		pkgs = append(pkgs, pkg)
+	}
+	return pkgs, nil
+}
+
 // ImportFrom returns the imported package for the given import path when
 // imported by a package file located in dir.
 // See go/types.ImporterFrom.
@@ -456,7 +637,7 @@ done:
 }
 
 // FindImplementers lists all types in all imported user packages which
-// implement the interface supplied in the argument.
+// implement the interface supplied in the argument, using ImplementersOf.
 //
 // This does not yield types from system packages or vendor packages yet.
 //
@@ -479,36 +660,53 @@ func (t *TypePackageSet) FindImplementers(ifaceName TypeName) (TypeMap, error) {
 	}
 
 	var implements = make(TypeMap)
+	for _, impl := range t.ImplementersOf(ifaceTyp) {
+		implements[impl.Name] = impl.Type
+	}
+	return implements, nil
+}
 
-	for _, fobj := range t.Objects {
-		fTyp := fobj.Type()
+// Implementer is a type that implements an interface.
+type Implementer struct {
+	Name TypeName
 
-		if ifaceTyp != fTyp && !types.IsInterface(fTyp) {
-			var impl types.Type
+	// Type is either the named type, or a pointer to the named type if only
+	// the pointer implements the interface (i.e. the interface's methods are
+	// declared with pointer receivers).
+	Type types.Type
 
-			if types.AssignableTo(fTyp, ifaceTyp) {
-				impl = fTyp
-			} else {
-				ptr := types.NewPointer(fTyp)
-				if types.AssignableTo(ptr, ifaceTyp) {
-					impl = ptr
-				}
-			}
+	// Pointer is true if Type is a pointer.
+	Pointer bool
+}
 
-			if impl != nil {
-				// var s types.Type
-				// if p, ok := fTyp.(*types.Pointer); ok {
-				//     s = p.Elem()
-				// } else {
-				//     s = fTyp
-				// }
-				// implements[ExtractTypeName(s)] = fTyp
-				implements[ExtractTypeName(fTyp)] = impl
-			}
-		}
+// ImplementersOf lists the types declared in all imported user packages that
+// implement iface, which must be an interface type. Interfaces are never
+// included, so every other type implements an empty interface. The result is
+// sorted by name. FindImplementers returns the same types.
+func (t *TypePackageSet) ImplementersOf(iface types.Type) []Implementer {
+	if !types.IsInterface(iface) {
+		return nil
 	}
 
-	return implements, nil
+	var impls []Implementer
+	for name, obj := range t.Objects {
+		if _, ok := obj.(*types.TypeName); !ok {
+			continue
+		}
+		typ := obj.Type()
+		if types.IsInterface(typ) {
+			continue
+		}
+		if types.AssignableTo(typ, iface) {
+			impls = append(impls, Implementer{Name: name, Type: typ})
+		} else if ptr := types.NewPointer(typ); types.AssignableTo(ptr, iface) {
+			impls = append(impls, Implementer{Name: name, Type: ptr, Pointer: true})
+		}
+	}
+	sort.Slice(impls, func(i, j int) bool {
+		return impls[i].Name.IsBefore(impls[j].Name)
+	})
+	return impls
 }
 
 func (t *TypePackageSet) FindObject(name TypeName) types.Object {
@@ -657,6 +855,33 @@ func (t *TypePackageSet) FieldDoc(tn TypeName, field string) (docstr string, err
 	return
 }
 
+// Sizes returns the types.Sizes for the GOARCH in the TypePackageSet's Config.
+func (t *TypePackageSet) Sizes() (types.Sizes, error) {
+	goarch := t.Config.GOARCH
+	if goarch == "" {
+		goarch = BuildContext.GOARCH
+	}
+	return SizesFor(goarch)
+}
+
+// Layout returns the memory layout of the struct type tn, calculated for the
+// GOARCH in the TypePackageSet's Config.
+func (t *TypePackageSet) Layout(tn TypeName) (*StructLayout, error) {
+	tobj := t.FindObject(tn)
+	if tobj == nil {
+		return nil, fmt.Errorf("type %s not found", tn)
+	}
+	stct, ok := tobj.Type().Underlying().(*types.Struct)
+	if !ok {
+		return nil, fmt.Errorf("type %s is not a struct", tn)
+	}
+	sizes, err := t.Sizes()
+	if err != nil {
+		return nil, err
+	}
+	return NewStructLayout(stct, sizes), nil
+}
+
 func resolvePackageDir(dir string) string {
 	info, err := os.Stat(dir)
 	if err != nil || !info.IsDir() {
@@ -664,14 +889,3 @@ func resolvePackageDir(dir string) string {
 	}
 	return dir
 }
-
-// isEnum checks if the type contains the "IsEnum()" function
-func isEnum(def types.Object, named *types.Named) bool {
-	mset := types.NewMethodSet(named)
-	sel := mset.Lookup(def.Pkg(), "IsEnum")
-	if sel != nil && sel.Kind() == types.MethodVal {
-		sig := sel.Type().(*types.Signature)
-		return sig.Params().Len() == 0 && sig.Results().Len() == 0
-	}
-	return false
-}
@@ -0,0 +1,319 @@
+package structer
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/types"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Transform contains callbacks used by TransformType to derive a new type from
+// an existing one. Either callback may be nil.
+type Transform struct {
+	// Type is called for every node before its children are transformed.
+	// Return t unchanged to keep the node and transform its children, or
+	// return a different type to replace the node entirely. The replacement
+	// is not descended into.
+	//
+	// As with Walk, named types are not descended into, but they may be
+	// replaced.
+	Type func(path WalkPath, t types.Type) (types.Type, error)
+
+	// Field is called for every struct field after the field's type has been
+	// transformed. Return a nil field to remove it from the struct. The tag
+	// may be changed by returning a different one.
+	Field func(path WalkPath, field *types.Var, tag string) (*types.Var, string, error)
+}
+
+// TransformType derives a new type from t by applying each of the transforms
+// to every node. If there is more than one Transform, they are applied in
+// order to each node. Nodes that are unchanged (along with all of their
+// children) are reused rather than copied.
+func TransformType(t types.Type, transforms ...Transform) (types.Type, error) {
+	tr := &transformer{transforms: transforms}
+	return tr.transform(t)
+}
+
+// TransformDecl transforms the type tn using TransformType and renders the
+// result as a declaration of the type target using DeclSource. It returns the
+// transformed type (which is not named) and the source.
+func (t *TypePackageSet) TransformDecl(tn TypeName, target TypeName, transforms ...Transform) (types.Type, []byte, error) {
+	obj := t.FindObject(tn)
+	if obj == nil {
+		return nil, nil, fmt.Errorf("type %s not found", tn)
+	}
+	typ, err := TransformType(obj.Type().Underlying(), transforms...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pkgName := path.Base(target.PackagePath)
+	if tpkg := t.TypePackages[target.PackagePath]; tpkg != nil {
+		pkgName = tpkg.Name()
+	}
+	src, err := DeclSource(target.PackagePath, pkgName, target.Name, typ)
+	if err != nil {
+		return nil, nil, err
+	}
+	return typ, src, nil
+}
+
+// ReplaceNamedTransform replaces every reference to the named type tn with
+// the type with, i.e. to replace time.Time with int64:
+//
+//	ReplaceNamedTransform(NewTypeName("time", "Time"), types.Typ[types.Int64])
+//
+func ReplaceNamedTransform(tn TypeName, with types.Type) Transform {
+	return Transform{
+		Type: func(path WalkPath, t types.Type) (types.Type, error) {
+			if named, ok := t.(*types.Named); ok && tn.IsType(named) {
+				return with, nil
+			}
+			return t, nil
+		},
+	}
+}
+
+// PointerFieldsTransform changes the type of every field that is not already
+// a pointer to a pointer to its type, i.e. to produce a patch type. Only the
+// fields of the root struct are changed.
+func PointerFieldsTransform() Transform {
+	return Transform{
+		Field: func(path WalkPath, field *types.Var, tag string) (*types.Var, string, error) {
+			if len(path) != 1 {
+				return field, tag, nil
+			}
+			if _, ok := field.Type().(*types.Pointer); ok {
+				return field, tag, nil
+			}
+			return types.NewField(field.Pos(), field.Pkg(), field.Name(), types.NewPointer(field.Type()), field.Anonymous()), tag, nil
+		},
+	}
+}
+
+// StripUnexportedTransform removes every unexported field.
+func StripUnexportedTransform() Transform {
+	return Transform{
+		Field: func(path WalkPath, field *types.Var, tag string) (*types.Var, string, error) {
+			if !field.Exported() {
+				return nil, "", nil
+			}
+			return field, tag, nil
+		},
+	}
+}
+
+type transformer struct {
+	transforms []Transform
+	path       WalkPath
+}
+
+func (tr *transformer) pathCopy() WalkPath {
+	path := make(WalkPath, len(tr.path))
+	copy(path, tr.path)
+	return path
+}
+
+func (tr *transformer) transform(t types.Type) (types.Type, error) {
+	nt := t
+	for _, x := range tr.transforms {
+		if x.Type == nil {
+			continue
+		}
+		var err error
+		if nt, err = x.Type(tr.pathCopy(), nt); err != nil {
+			return nil, err
+		}
+	}
+	if nt != t {
+		return nt, nil
+	}
+
+	switch t := t.(type) {
+	case *types.Struct:
+		return tr.transformStruct(t)
+
+	case *types.Map:
+		key, err := tr.transformElem(PathElem{Kind: PathMapKey, Type: t}, t.Key())
+		if err != nil {
+			return nil, err
+		}
+		elem, err := tr.transformElem(PathElem{Kind: PathMapElem, Type: t}, t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		if key != t.Key() || elem != t.Elem() {
+			return types.NewMap(key, elem), nil
+		}
+
+	case *types.Slice:
+		elem, err := tr.transformElem(PathElem{Kind: PathSliceElem, Type: t}, t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		if elem != t.Elem() {
+			return types.NewSlice(elem), nil
+		}
+
+	case *types.Array:
+		elem, err := tr.transformElem(PathElem{Kind: PathArrayElem, Type: t}, t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		if elem != t.Elem() {
+			return types.NewArray(elem, t.Len()), nil
+		}
+
+	case *types.Pointer:
+		elem, err := tr.transformElem(PathElem{Kind: PathDeref, Type: t}, t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		if elem != t.Elem() {
+			return types.NewPointer(elem), nil
+		}
+	}
+
+	return t, nil
+}
+
+func (tr *transformer) transformElem(elem PathElem, t types.Type) (types.Type, error) {
+	tr.path = append(tr.path, elem)
+	defer func() { tr.path = tr.path[:len(tr.path)-1] }()
+	return tr.transform(t)
+}
+
+func (tr *transformer) transformStruct(s *types.Struct) (types.Type, error) {
+	changed := false
+	var fields []*types.Var
+	var tags []string
+
+	for i := 0; i < s.NumFields(); i++ {
+		field, tag := s.Field(i), s.Tag(i)
+		nfield, ntag, err := tr.transformField(s, i, field, tag)
+		if err != nil {
+			return nil, err
+		}
+		if nfield != field || ntag != tag {
+			changed = true
+		}
+		if nfield != nil {
+			fields = append(fields, nfield)
+			tags = append(tags, ntag)
+		}
+	}
+
+	if !changed {
+		return s, nil
+	}
+	return types.NewStruct(fields, tags), nil
+}
+
+func (tr *transformer) transformField(s *types.Struct, idx int, field *types.Var, tag string) (*types.Var, string, error) {
+	tr.path = append(tr.path, PathElem{Kind: PathField, Type: s, Field: field, Index: idx})
+	defer func() { tr.path = tr.path[:len(tr.path)-1] }()
+
+	ft, err := tr.transform(field.Type())
+	if err != nil {
+		return nil, "", err
+	}
+	if ft != field.Type() {
+		// Only named types (and pointers to them) may be embedded.
+		embedded := field.Anonymous() && namedOf(ft) != nil
+		field = types.NewField(field.Pos(), field.Pkg(), field.Name(), ft, embedded)
+	}
+
+	for _, x := range tr.transforms {
+		if x.Field == nil {
+			continue
+		}
+		if field, tag, err = x.Field(tr.pathCopy(), field, tag); err != nil {
+			return nil, "", err
+		}
+		if field == nil {
+			return nil, "", nil
+		}
+	}
+	return field, tag, nil
+}
+
+// DeclSource renders a declaration for a type called name with the
+// underlying type t, in the package with the path pkgPath called pkgName. The
+// result is a complete, formatted Go file containing the package clause, the
+// imports required by t and the type declaration.
+//
+// Struct tags are rendered as raw strings where possible.
+//
+func DeclSource(pkgPath, pkgName, name string, t types.Type) ([]byte, error) {
+	w := &declWriter{imports: NewImportNames(pkgPath)}
+	w.writeType(t)
+	body := w.buf.String()
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "package %s\n\n", pkgName)
+	if imports := w.imports.Source(); imports != nil {
+		buf.Write(imports)
+		buf.WriteString("\n")
+	}
+	fmt.Fprintf(&buf, "type %s %s\n", name, body)
+
+	return format.Source(buf.Bytes())
+}
+
+type declWriter struct {
+	buf     bytes.Buffer
+	imports *ImportNames
+}
+
+func (w *declWriter) writeType(t types.Type) {
+	switch t := t.(type) {
+	case *types.Struct:
+		if t.NumFields() == 0 {
+			w.buf.WriteString("struct{}")
+			return
+		}
+		w.buf.WriteString("struct {\n")
+		for i := 0; i < t.NumFields(); i++ {
+			field := t.Field(i)
+			if !field.Anonymous() {
+				w.buf.WriteString(field.Name())
+				w.buf.WriteString(" ")
+			}
+			w.writeType(field.Type())
+			if tag := t.Tag(i); tag != "" {
+				w.buf.WriteString(" ")
+				if strings.Contains(tag, "`") {
+					w.buf.WriteString(strconv.Quote(tag))
+				} else {
+					w.buf.WriteString("`" + tag + "`")
+				}
+			}
+			w.buf.WriteString("\n")
+		}
+		w.buf.WriteString("}")
+
+	case *types.Pointer:
+		w.buf.WriteString("*")
+		w.writeType(t.Elem())
+
+	case *types.Slice:
+		w.buf.WriteString("[]")
+		w.writeType(t.Elem())
+
+	case *types.Array:
+		fmt.Fprintf(&w.buf, "[%d]", t.Len())
+		w.writeType(t.Elem())
+
+	case *types.Map:
+		w.buf.WriteString("map[")
+		w.writeType(t.Key())
+		w.buf.WriteString("]")
+		w.writeType(t.Elem())
+
+	default:
+		w.buf.WriteString(types.TypeString(t, w.imports.Qualifier))
+	}
+}
@@ -0,0 +1,88 @@
+package structer
+
+import (
+	"go/types"
+	"testing"
+)
+
+func TestTransformTypeUnchanged(t *testing.T) {
+	pkg := "github.com/shabbyrobe/structer/testpkg/transform"
+	tpset := NewTypePackageSet()
+	if _, err := tpset.Import(pkg); err != nil {
+		t.Fatal(err)
+	}
+	typ := tpset.MustFindObject(NewTypeName(pkg, "Event")).Type().Underlying()
+
+	out, err := TransformType(typ, ReplaceNamedTransform(NewTypeName("net", "IP"), types.Typ[types.String]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != typ {
+		t.Fatal("expected unchanged type to be reused")
+	}
+}
+
+func TestTransformDecl(t *testing.T) {
+	pkg := "github.com/shabbyrobe/structer/testpkg/transform"
+	tpset := NewTypePackageSet()
+	if _, err := tpset.Import(pkg); err != nil {
+		t.Fatal(err)
+	}
+
+	var paths []string
+	record := Transform{
+		Field: func(path WalkPath, field *types.Var, tag string) (*types.Var, string, error) {
+			paths = append(paths, path.Expr("v"))
+			return field, tag, nil
+		},
+	}
+
+	typ, src, err := tpset.TransformDecl(
+		NewTypeName(pkg, "Event"),
+		NewTypeName("example.com/dto", "EventPatch"),
+		ReplaceNamedTransform(NewTypeName("time", "Time"), types.Typ[types.Int64]),
+		StripUnexportedTransform(),
+		PointerFieldsTransform(),
+		record,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "package dto\n" +
+		"\n" +
+		"import (\n" +
+		"\t\"github.com/shabbyrobe/structer/testpkg/transform\"\n" +
+		")\n" +
+		"\n" +
+		"type EventPatch struct {\n" +
+		"\tID     *int64 `json:\"id\"`\n" +
+		"\tWhen   *int64 `json:\"when\"`\n" +
+		"\tTags   *[]string\n" +
+		"\tMeta   *map[string]int64\n" +
+		"\tRef    *transform.Event\n" +
+		"\tNested *struct {\n" +
+		"\t\tAt int64 `json:\"at\"`\n" +
+		"\t}\n" +
+		"}\n"
+	if string(src) != expected {
+		t.Fatalf("\n%s\n!=\n%s", src, expected)
+	}
+
+	stct := typ.(*types.Struct)
+	if stct.NumFields() != 6 || stct.Tag(1) != `json:"when"` {
+		t.Fatal(stct)
+	}
+
+	expectedPaths := "v.ID v.When v.Tags v.Meta v.Ref v.Nested.At v.Nested"
+	var found string
+	for i, p := range paths {
+		if i > 0 {
+			found += " "
+		}
+		found += p
+	}
+	if found != expectedPaths {
+		t.Fatal(found)
+	}
+}
@@ -0,0 +1,39 @@
+package structer
+
+import (
+	"fmt"
+	"go/token"
+)
+
+// WalkError wraps an error returned by a TypeVisitor with the location in the
+// walked type where it occurred. The original error is available using
+// Unwrap, so errors.Is and errors.As will still match it.
+type WalkError struct {
+	// Root is the TypeName passed to Walk.
+	Root TypeName
+
+	// Path to the node being visited when the error was returned.
+	Path WalkPath
+
+	// Pos is the position of the innermost field in Path, if any.
+	Pos token.Pos
+
+	// Position is only populated if a TypePackageSet is passed to Walk using
+	// WalkTypePackageSet.
+	Position token.Position
+
+	Err error
+}
+
+func (e *WalkError) Error() string {
+	msg := fmt.Sprintf("walk %s", e.Root)
+	if len(e.Path) > 0 {
+		msg += fmt.Sprintf(" at %s", e.Path.Expr(e.Root.Name))
+	}
+	if e.Position.IsValid() {
+		msg += fmt.Sprintf(" (%s)", e.Position)
+	}
+	return msg + ": " + e.Err.Error()
+}
+
+func (e *WalkError) Unwrap() error { return e.Err }
@@ -0,0 +1,58 @@
+package structer
+
+import (
+	"errors"
+	"go/types"
+	"strings"
+	"testing"
+)
+
+var errTestUnsupported = errors.New("unsupported type")
+
+func TestWalkError(t *testing.T) {
+	tpset, tn, tst := getTestingStruct(t, "github.com/shabbyrobe/structer.TestingControlStruct")
+	vis := &controlVisitor{Returns: map[string]error{
+		"VisitBasic v.Map[k1]": errTestUnsupported,
+	}}
+	err := Walk(tn, tst, vis, WalkTypePackageSet(tpset))
+	if !errors.Is(err, errTestUnsupported) {
+		t.Fatal(err)
+	}
+	var werr *WalkError
+	if !errors.As(err, &werr) {
+		t.Fatal(err)
+	}
+	if werr.Root != tn {
+		t.Fatal(werr.Root)
+	}
+	if expr := werr.Path.Expr("v"); expr != "v.Map[k1]" {
+		t.Fatal(expr)
+	}
+	mapField := tst.(*types.Struct).Field(1)
+	if werr.Pos != mapField.Pos() || werr.Position != tpset.ASTPackages.FileSet.Position(mapField.Pos()) {
+		t.Fatal(werr.Position)
+	}
+	if !strings.HasSuffix(werr.Position.Filename, "struct_test.go") {
+		t.Fatal(werr.Position)
+	}
+	if !strings.Contains(err.Error(), "TestingControlStruct.Map[k1]") {
+		t.Fatal(err)
+	}
+}
+
+func TestWalkErrorNested(t *testing.T) {
+	_, tn, tst := getTestingStruct(t, "github.com/shabbyrobe/structer.TestingControlStruct")
+
+	// Errors from a nested Walk are not wrapped a second time.
+	inner := &WalkError{Root: tn, Err: errTestUnsupported}
+	vis := &controlVisitor{Returns: map[string]error{
+		"EnterField v.Slice": inner,
+	}}
+	err := Walk(tn, tst, vis)
+	if err != inner {
+		t.Fatal(err)
+	}
+	if err.Error() != "walk github.com/shabbyrobe/structer.TestingControlStruct: unsupported type" {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,225 @@
+package structer
+
+import (
+	"fmt"
+	"go/types"
+	"strings"
+	"unicode"
+)
+
+// CaseStrategy converts a Go field name into a wire name for fields that do
+// not have a name in their tag.
+type CaseStrategy int
+
+const (
+	// CaseNone uses the Go field name unchanged, i.e. "HTTPServerID".
+	CaseNone CaseStrategy = iota
+
+	// CaseLower lowercases the whole name, i.e. "httpserverid". This is what
+	// gopkg.in/yaml.v2 and mgo's bson package do.
+	CaseLower
+
+	// CaseSnake produces "http_server_id".
+	CaseSnake
+
+	// CaseKebab produces "http-server-id".
+	CaseKebab
+
+	// CaseCamel produces "httpServerId".
+	CaseCamel
+
+	// CasePascal produces "HttpServerId".
+	CasePascal
+)
+
+func (c CaseStrategy) String() string {
+	switch c {
+	case CaseNone:
+		return "none"
+	case CaseLower:
+		return "lower"
+	case CaseSnake:
+		return "snake"
+	case CaseKebab:
+		return "kebab"
+	case CaseCamel:
+		return "camel"
+	case CasePascal:
+		return "pascal"
+	default:
+		return fmt.Sprintf("CaseStrategy(%d)", int(c))
+	}
+}
+
+// Convert applies the strategy to a Go identifier.
+func (c CaseStrategy) Convert(name string) string {
+	switch c {
+	case CaseLower:
+		return strings.ToLower(name)
+	case CaseSnake:
+		return strings.ToLower(strings.Join(splitWords(name), "_"))
+	case CaseKebab:
+		return strings.ToLower(strings.Join(splitWords(name), "-"))
+	case CaseCamel, CasePascal:
+		words := splitWords(name)
+		for i, w := range words {
+			w = strings.ToLower(w)
+			if i > 0 || c == CasePascal {
+				w = upperFirst(w)
+			}
+			words[i] = w
+		}
+		return strings.Join(words, "")
+	default:
+		return name
+	}
+}
+
+// NamingPolicy describes how a serialisation package maps struct fields to
+// names on the wire.
+type NamingPolicy struct {
+	// TagKey is the struct tag key to take names and options from, i.e.
+	// "json". If it is empty, only Case is used.
+	TagKey string
+
+	// Case is applied to the Go field name if the tag does not supply a name.
+	Case CaseStrategy
+
+	// InlineEmbedded causes embedded structs (and pointers to structs) that
+	// do not have a name in their tag to be flattened into the containing
+	// struct, like encoding/json.
+	InlineEmbedded bool
+
+	// InlineOption is a tag option that causes a struct field to be
+	// flattened into the containing struct, i.e. "inline" for yaml.
+	InlineOption string
+}
+
+// Naming policies for common serialisation packages.
+var (
+	JSONNaming    = NamingPolicy{TagKey: "json", InlineEmbedded: true}
+	MsgpackNaming = NamingPolicy{TagKey: "msgpack", InlineEmbedded: true}
+	YAMLNaming    = NamingPolicy{TagKey: "yaml", Case: CaseLower, InlineOption: "inline"}
+	BSONNaming    = NamingPolicy{TagKey: "bson", Case: CaseLower, InlineOption: "inline"}
+)
+
+// WireField describes how a single struct field is represented by a
+// serialisation package, as resolved by NamingPolicy.Resolve.
+type WireField struct {
+	Field *types.Var
+
+	// Name on the wire. This is empty if Skip is true.
+	Name string
+
+	// Tagged is true if Name came from the tag rather than the field name.
+	Tagged bool
+
+	// Skip is true if the field is not serialised, either because it is
+	// tagged with "-" or because it is unexported.
+	Skip bool
+
+	// Inline is true if the field's own fields should be flattened into the
+	// containing struct. Name should not be used on the wire if Inline is
+	// true.
+	Inline bool
+
+	OmitEmpty bool
+	OmitZero  bool
+
+	// Options contains all of the tag options, including omitempty and
+	// omitzero.
+	Options []string
+}
+
+// HasOption reports whether opt is one of the field's tag options.
+func (w WireField) HasOption(opt string) bool {
+	for _, o := range w.Options {
+		if o == opt {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolve calculates the wire representation of field using its parsed tag.
+//
+// Unexported fields are skipped unless they are embedded structs, which can
+// still contribute exported fields if they are inlined. A field tagged
+// with exactly "-" is skipped; "-," names the field "-", as with
+// encoding/json.
+//
+func (p NamingPolicy) Resolve(field *types.Var, tag StructTag) WireField {
+	wf := WireField{Field: field, Name: field.Name()}
+
+	if p.TagKey != "" {
+		if tk, ok := tag.Lookup(p.TagKey); ok {
+			if tk.Value == "-" {
+				return WireField{Field: field, Skip: true}
+			}
+			if tk.Name != "" {
+				wf.Name, wf.Tagged = tk.Name, true
+			}
+			wf.Options = tk.Options
+			wf.OmitEmpty = tk.HasOption("omitempty")
+			wf.OmitZero = tk.HasOption("omitzero")
+		}
+	}
+
+	embedded := embeddedStruct(field)
+	if !field.Exported() && embedded == nil {
+		return WireField{Field: field, Skip: true}
+	}
+
+	if p.InlineOption != "" && wf.HasOption(p.InlineOption) {
+		wf.Inline = true
+	} else if p.InlineEmbedded && embedded != nil && !wf.Tagged {
+		wf.Inline = true
+	}
+
+	if !wf.Inline && !field.Exported() {
+		return WireField{Field: field, Skip: true}
+	}
+	if !wf.Tagged {
+		wf.Name = p.Case.Convert(wf.Name)
+	}
+	return wf
+}
+
+// splitWords splits a Go identifier into words, keeping initialisms
+// together: "HTTPServerID" becomes "HTTP", "Server", "ID". Digits are kept
+// with the preceding word and underscores are treated as separators.
+func splitWords(name string) []string {
+	var words []string
+	runes := []rune(name)
+	start := 0
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if r == '_' {
+			if i > start {
+				words = append(words, string(runes[start:i]))
+			}
+			start = i + 1
+			continue
+		}
+		if i == start || !unicode.IsUpper(r) {
+			continue
+		}
+		prev := runes[i-1]
+		nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+		if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+	if start < len(runes) {
+		words = append(words, string(runes[start:]))
+	}
+	return words
+}
+
+func upperFirst(s string) string {
+	for i, r := range s {
+		return string(unicode.ToUpper(r)) + s[i+len(string(r)):]
+	}
+	return s
+}
@@ -0,0 +1,100 @@
+package structer
+
+import (
+	"go/types"
+	"reflect"
+	"testing"
+)
+
+func TestCaseStrategy(t *testing.T) {
+	for _, tc := range []struct {
+		in  string
+		c   CaseStrategy
+		out string
+	}{
+		{"HTTPServerID", CaseNone, "HTTPServerID"},
+		{"HTTPServerID", CaseLower, "httpserverid"},
+		{"HTTPServerID", CaseSnake, "http_server_id"},
+		{"HTTPServerID", CaseKebab, "http-server-id"},
+		{"HTTPServerID", CaseCamel, "httpServerId"},
+		{"HTTPServerID", CasePascal, "HttpServerId"},
+		{"Field2Name", CaseSnake, "field2_name"},
+		{"already_snake", CaseCamel, "alreadySnake"},
+		{"ID", CaseSnake, "id"},
+		{"X", CaseCamel, "x"},
+	} {
+		if out := tc.c.Convert(tc.in); out != tc.out {
+			t.Fatalf("%s %s: expected %q, found %q", tc.c, tc.in, tc.out, out)
+		}
+	}
+}
+
+func TestNamingPolicyResolve(t *testing.T) {
+	field := types.NewField(0, nil, "UserName", types.Typ[types.String], false)
+
+	for _, tc := range []struct {
+		policy NamingPolicy
+		tag    string
+		out    WireField
+	}{
+		{JSONNaming, ``, WireField{Name: "UserName"}},
+		{JSONNaming, `json:"user,omitempty"`, WireField{Name: "user", Tagged: true, OmitEmpty: true, Options: []string{"omitempty"}}},
+		{JSONNaming, `json:",omitzero"`, WireField{Name: "UserName", OmitZero: true, Options: []string{"omitzero"}}},
+		{JSONNaming, `json:"-"`, WireField{Skip: true}},
+		{JSONNaming, `json:"-,"`, WireField{Name: "-", Tagged: true, Options: []string{""}}},
+		{YAMLNaming, `json:"user"`, WireField{Name: "username"}},
+		{NamingPolicy{TagKey: "custom", Case: CaseSnake}, `custom:",omitempty"`, WireField{Name: "user_name", OmitEmpty: true, Options: []string{"omitempty"}}},
+	} {
+		tc.out.Field = field
+		wf := tc.policy.Resolve(field, ParseStructTag(tc.tag))
+		if !reflect.DeepEqual(tc.out, wf) {
+			t.Fatalf("%s: expected %+v, found %+v", tc.tag, tc.out, wf)
+		}
+	}
+}
+
+func TestNamingPolicyEffectiveFields(t *testing.T) {
+	pkg := "github.com/shabbyrobe/structer/testpkg/wire"
+	tpset := NewTypePackageSet()
+	if _, err := tpset.Import(pkg); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		policy NamingPolicy
+		names  []string
+	}{
+		{"json", JSONNaming, []string{"id", "created_at", "meta", "HTTPPort", "UserName", "-", "Deleted"}},
+		{"msgpack", MsgpackNaming, []string{"ID", "created_at", "Labels", "port", "UserName", "Deleted"}},
+		{"yaml", YAMLNaming, []string{"base", "labels", "httpport", "user", "dash", "deleted"}},
+		{"bson", BSONNaming, []string{"base", "labels", "httpport", "username", "dash", "deleted"}},
+		{"snake", NamingPolicy{Case: CaseSnake, InlineEmbedded: true}, []string{"id", "created_at", "labels", "http_port", "user_name", "dash", "ignored", "deleted"}},
+	} {
+		policy := tc.policy
+		fs, err := tpset.EffectiveFields(NewTypeName(pkg, "Record"), FieldSetOptions{Naming: &policy})
+		if err != nil {
+			t.Fatal(err)
+		}
+		names, conflicts := fieldSetNames(fs)
+		if !reflect.DeepEqual(tc.names, names) {
+			t.Fatalf("%s: names %v != %v", tc.name, names, tc.names)
+		}
+		if len(conflicts) > 0 {
+			t.Fatalf("%s: unexpected conflicts %v", tc.name, conflicts)
+		}
+	}
+
+	fs, err := tpset.EffectiveFields(NewTypeName(pkg, "Record"), FieldSetOptions{Naming: &JSONNaming})
+	if err != nil {
+		t.Fatal(err)
+	}
+	deleted, _ := fs.Lookup("Deleted")
+	if !deleted.Wire.OmitEmpty || !deleted.Wire.OmitZero {
+		t.Fatal(deleted.Wire)
+	}
+	created, _ := fs.Lookup("created_at")
+	if created.Depth != 1 || !created.Wire.OmitEmpty {
+		t.Fatal(created)
+	}
+}